@@ -0,0 +1,139 @@
+package ld
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RequestID returns a zap.Field for a request ID.
+func RequestID(id string) zap.Field { return zap.String("request-id", id) }
+
+// TraceID returns a zap.Field for a distributed trace ID.
+func TraceID(id string) zap.Field { return zap.String("trace-id", id) }
+
+// UserID returns a zap.Field for a user ID.
+func UserID(id string) zap.Field { return zap.String("user-id", id) }
+
+// TenantID returns a zap.Field for a tenant ID.
+func TenantID(id string) zap.Field { return zap.String("tenant-id", id) }
+
+// StatusCode returns a zap.Field for an HTTP status code.
+func StatusCode(code int) zap.Field { return zap.Int("status", code) }
+
+// Latency returns a zap.Field for the time taken to serve a request.
+func Latency(d time.Duration) zap.Field { return zap.Duration("latency", d) }
+
+// BytesIn returns a zap.Field for the number of bytes received.
+func BytesIn(n int64) zap.Field { return Bytes("bytes-in", n) }
+
+// BytesOut returns a zap.Field for the number of bytes sent.
+func BytesOut(n int64) zap.Field { return Bytes("bytes-out", n) }
+
+// Bytes returns a zap.Field for a number of bytes.  The value is encoded as an integer, loggers using
+// console encoding render it in human-readable form, such as "1.5 KiB".
+func Bytes(key string, n int64) zap.Field {
+	return zap.Field{Key: key, Type: zapcore.Int64Type, Integer: n, Interface: ByteSize(n)}
+}
+
+// Host returns a zap.Field for a host name.
+func Host(host string) zap.Field { return zap.String("host", host) }
+
+// Path returns a zap.Field for a URL path.
+func Path(p string) zap.Field { return zap.String("path", p) }
+
+// Query returns a zap.Field for a raw URL query, with the values of SensitiveKeys redacted.
+func Query(rawQuery string) zap.Field { return zap.String("query", SanitizeQuery(rawQuery)) }
+
+// Referer returns a zap.Field for the referring URL.
+func Referer(referer string) zap.Field { return zap.String("referer", referer) }
+
+// ContentType returns a zap.Field for a content type.
+func ContentType(ct string) zap.Field { return zap.String("content-type", ct) }
+
+// Request returns the fields for the method, host, path, sanitised query, client IP, user agent, referer,
+// content type and request size of the request, omitting any that are empty.  They are separate top-level
+// fields, so they are humanised, schema checked and resolved against common fields like any other:
+//
+//	log.Info("request", ld.Request(r)...)
+func Request(r *http.Request) []zap.Field {
+	if r == nil {
+		return nil
+	}
+	fields := []zap.Field{Method(r.Method), Host(r.Host)}
+	if r.URL != nil {
+		fields = append(fields, Path(r.URL.Path))
+		if r.URL.RawQuery != "" {
+			fields = append(fields, Query(r.URL.RawQuery))
+		}
+	}
+	if r.RemoteAddr != "" {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+		fields = append(fields, IP(ip))
+	}
+	for _, f := range []zap.Field{UserAgent(r.UserAgent()), Referer(r.Referer()), ContentType(r.Header.Get("Content-Type"))} {
+		if f.String != "" {
+			fields = append(fields, f)
+		}
+	}
+	if r.ContentLength > 0 {
+		fields = append(fields, BytesIn(r.ContentLength))
+	}
+	return fields
+}
+
+// SanitizeQuery returns the raw query with the values of SensitiveKeys replaced by RedactedQueryValue.
+// Parameters are sorted by key, and a query that cannot be parsed is redacted entirely.
+func SanitizeQuery(rawQuery string) string {
+	return SanitizeQueryFunc(rawQuery, IsSensitiveKey)
+}
+
+// SanitizeQueryFunc is SanitizeQuery with the values of keys reported sensitive by the function redacted
+func SanitizeQueryFunc(rawQuery string, sensitive func(key string) bool) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return RedactedQueryValue
+	}
+	for key, vals := range values {
+		if sensitive(key) {
+			for i := range vals {
+				vals[i] = RedactedQueryValue
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// ByteSize is a number of bytes, rendered in human-readable binary units
+type ByteSize int64
+
+var byteUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// String implements fmt.Stringer
+func (b ByteSize) String() string {
+	if b < 1024 && b > -1024 {
+		return strconv.FormatInt(int64(b), 10) + " B"
+	}
+	value := float64(b)
+	unit := ""
+	for _, u := range byteUnits {
+		value /= 1024
+		unit = u
+		if value < 1024 && value > -1024 {
+			break
+		}
+	}
+	return strings.TrimSuffix(strconv.FormatFloat(value, 'f', 1, 64), ".0") + " " + unit
+}
@@ -0,0 +1,108 @@
+package ld
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDomainFields(t *testing.T) {
+	tests := []struct {
+		field zap.Field
+		key   string
+		want  any
+	}{
+		{RequestID("r1"), "request-id", "r1"},
+		{TraceID("t1"), "trace-id", "t1"},
+		{UserID("u1"), "user-id", "u1"},
+		{TenantID("t2"), "tenant-id", "t2"},
+		{StatusCode(404), "status", int64(404)},
+		{Latency(time.Second), "latency", time.Second},
+		{BytesIn(10), "bytes-in", int64(10)},
+		{BytesOut(20), "bytes-out", int64(20)},
+		{Host("example.com"), "host", "example.com"},
+		{Path("/a"), "path", "/a"},
+		{Query("page=2&token=abc"), "query", "page=2&token=REDACTED"},
+		{Referer("https://example.com"), "referer", "https://example.com"},
+		{ContentType("text/plain"), "content-type", "text/plain"},
+	}
+	for _, test := range tests {
+		enc := zapcore.NewMapObjectEncoder()
+		test.field.AddTo(enc)
+		if got := enc.Fields[test.key]; got != test.want {
+			t.Errorf("%s: got %v (%T), want %v (%T)", test.key, got, got, test.want, test.want)
+		}
+	}
+}
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"":                            "",
+		"b=2&a=1":                     "a=1&b=2",
+		"access_token=x&API_KEY=y&q=": "API_KEY=REDACTED&access_token=REDACTED&q=",
+		"password=a&password=b":       "password=REDACTED&password=REDACTED",
+		"%zz":                         RedactedQueryValue,
+	}
+	for input, want := range tests {
+		if got := SanitizeQuery(input); got != want {
+			t.Errorf("SanitizeQuery(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	tests := map[ByteSize]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1 KiB",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5 MiB",
+		3 << 30:         "3 GiB",
+		-2048:           "-2 KiB",
+		1<<40 + 1<<39:   "1.5 TiB",
+	}
+	for size, want := range tests {
+		if got := size.String(); got != want {
+			t.Errorf("ByteSize(%d) = %q, want %q", int64(size), got, want)
+		}
+	}
+}
+
+func TestRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/items?id=1&signature=abc", strings.NewReader("body"))
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("User-Agent", "test")
+	r.Header.Set("Content-Type", "text/plain")
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range Request(r) {
+		f.AddTo(enc)
+	}
+	want := map[string]any{
+		"method":       "POST",
+		"host":         "example.com",
+		"path":         "/items",
+		"query":        "id=1&signature=REDACTED",
+		"ip":           "10.0.0.1",
+		"user-agent":   "test",
+		"content-type": "text/plain",
+		"bytes-in":     int64(4),
+	}
+	if len(enc.Fields) != len(want) {
+		t.Errorf("Request: got %v, want %v", enc.Fields, want)
+	}
+	for key, value := range want {
+		if enc.Fields[key] != value {
+			t.Errorf("Request %s: got %v, want %v", key, enc.Fields[key], value)
+		}
+	}
+
+	if len(Request(nil)) != 0 {
+		t.Errorf("Request(nil) should have no fields")
+	}
+}
@@ -0,0 +1,109 @@
+package ld
+
+import (
+	"reflect"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxErrorDepth guards against cyclic or pathologically deep error trees
+const maxErrorDepth = 32
+
+// LogFielder is implemented by errors that carry their own log fields.
+// The fields are appended automatically when the error is logged by a logger.Logger.
+type LogFielder interface {
+	LogFields() []zap.Field
+}
+
+// ErrorChain returns a zap.Field encoding the tree of errors produced by errors.Unwrap and errors.Join
+// as an array of {type, message} objects, or zap.Skip() if the error is nil.
+// Joined errors include a "joined" array holding the chain of each joined error.
+func ErrorChain(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Array(key, errorChain{err: err})
+}
+
+// IsWrapped reports whether the error wraps, or joins, any other errors
+func IsWrapped(err error) bool {
+	switch err.(type) {
+	case interface{ Unwrap() error }, interface{ Unwrap() []error }:
+		return true
+	}
+	return false
+}
+
+// ErrorFields returns the fields of every error within the tree implementing LogFielder, outermost first
+func ErrorFields(err error) []zap.Field {
+	var fields []zap.Field
+	walkErrors(err, 0, func(e error) {
+		if lf, ok := e.(LogFielder); ok {
+			fields = append(fields, lf.LogFields()...)
+		}
+	})
+	return fields
+}
+
+func walkErrors(err error, depth int, fn func(error)) {
+	if err == nil || depth > maxErrorDepth {
+		return
+	}
+	fn(err)
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(e.Unwrap(), depth+1, fn)
+	case interface{ Unwrap() []error }:
+		for _, joined := range e.Unwrap() {
+			walkErrors(joined, depth+1, fn)
+		}
+	}
+}
+
+type errorChain struct {
+	err   error
+	depth int
+}
+
+// MarshalLogArray implements zapcore.ArrayMarshaler
+func (c errorChain) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	depth := c.depth
+	for err := c.err; err != nil && depth <= maxErrorDepth; depth++ {
+		link := errorLink{err: err, depth: depth}
+		if e := enc.AppendObject(link); e != nil {
+			return e
+		}
+		next, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = next.Unwrap()
+	}
+	return nil
+}
+
+type errorLink struct {
+	err   error
+	depth int
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler
+func (l errorLink) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("type", reflect.TypeOf(l.err).String())
+	enc.AddString("message", l.err.Error())
+	if joined, ok := l.err.(interface{ Unwrap() []error }); ok {
+		return enc.AddArray("joined", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, err := range joined.Unwrap() {
+				if err == nil {
+					continue
+				}
+				if e := arr.AppendArray(errorChain{err: err, depth: l.depth + 1}); e != nil {
+					return e
+				}
+			}
+			return nil
+		}))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package ld
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type fieldError struct {
+	id string
+}
+
+func (e *fieldError) Error() string { return "failed " + e.id }
+
+func (e *fieldError) LogFields() []zap.Field { return []zap.Field{zap.String("order-id", e.id)} }
+
+func TestErrorChain(t *testing.T) {
+	if ErrorChain("error.chain", nil).Type != zapcore.SkipType {
+		t.Errorf("expected nil error to be skipped")
+	}
+
+	base := &fs.PathError{Op: "open", Path: "/tmp/x", Err: fs.ErrNotExist}
+	err := fmt.Errorf("load: %w", errors.Join(base, errors.New("second")))
+
+	enc := zapcore.NewMapObjectEncoder()
+	ErrorChain("error.chain", err).AddTo(enc)
+	chain, ok := enc.Fields["error.chain"].([]interface{})
+	if !ok || len(chain) != 2 {
+		t.Fatalf("expected chain of 2, got %#v", enc.Fields["error.chain"])
+	}
+
+	first := chain[0].(map[string]interface{})
+	if first["type"] != "*fmt.wrapError" || first["message"] != err.Error() {
+		t.Errorf("unexpected first link %v", first)
+	}
+	second := chain[1].(map[string]interface{})
+	if second["type"] != "*errors.joinError" {
+		t.Errorf("unexpected second link type %v", second["type"])
+	}
+	joined := second["joined"].([]interface{})
+	if len(joined) != 2 {
+		t.Fatalf("expected 2 joined errors, got %d", len(joined))
+	}
+	pathChain := joined[0].([]interface{})
+	if len(pathChain) != 2 || pathChain[0].(map[string]interface{})["type"] != "*fs.PathError" {
+		t.Errorf("unexpected joined chain %v", pathChain)
+	}
+	if pathChain[1].(map[string]interface{})["message"] != "file does not exist" {
+		t.Errorf("unexpected root cause %v", pathChain[1])
+	}
+}
+
+func TestIsWrapped(t *testing.T) {
+	if IsWrapped(errors.New("plain")) {
+		t.Errorf("plain error should not be wrapped")
+	}
+	if !IsWrapped(fmt.Errorf("a: %w", errors.New("b"))) {
+		t.Errorf("fmt.Errorf %%w should be wrapped")
+	}
+	if !IsWrapped(errors.Join(errors.New("a"), errors.New("b"))) {
+		t.Errorf("joined errors should be wrapped")
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	err := fmt.Errorf("outer: %w", errors.Join(&fieldError{id: "1"}, fmt.Errorf("x: %w", &fieldError{id: "2"})))
+	fields := ErrorFields(err)
+	if len(fields) != 2 || fields[0].String != "1" || fields[1].String != "2" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+	if len(ErrorFields(errors.New("plain"))) != 0 {
+		t.Errorf("expected no fields for a plain error")
+	}
+}
@@ -7,15 +7,34 @@ import (
 	"reflect"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Prefix prepends a prefix to the field key, separated by a colon.  This is useful for grouping fields together.
+// Loggers configured with logger.NestedPrefixes encode prefixed keys as nested objects, see Group.
 func Prefix(prefix string, field zap.Field) zap.Field {
 	field.Key = prefix + ":" + field.Key
 	return field
 }
 
+// Group returns a zap.Field encoding the fields as a nested object under name
+func Group(name string, fields ...zap.Field) zap.Field {
+	return zap.Object(name, group(fields))
+}
+
+type group []zap.Field
+
+// MarshalLogObject implements zapcore.ObjectMarshaler
+func (g group) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range g {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
 // Error returns a zap.Field for an error, or zap.Skip() if the error is nil.
+// When logged through a logger.Logger, wrapped and joined errors also produce an error.chain field,
+// and the fields of any error implementing LogFielder are appended.
 func Error(err error) zap.Field {
 	if err == nil {
 		return zap.Skip()
@@ -2,6 +2,7 @@ package ld
 
 import (
 	"errors"
+	"reflect"
 	"testing"
 
 	"go.uber.org/zap/zapcore"
@@ -98,3 +99,12 @@ func TestUserAgent(t *testing.T) {
 		t.Errorf("incorrect userAgent key: got %s", result.Key)
 	}
 }
+
+func TestGroup(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	Group("req", Method("GET"), Group("client", IP("127.0.0.1"))).AddTo(enc)
+	want := map[string]any{"method": "GET", "client": map[string]any{"ip": "127.0.0.1"}}
+	if got := enc.Fields["req"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Group: got %v, want %v", got, want)
+	}
+}
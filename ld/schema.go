@@ -0,0 +1,202 @@
+package ld
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Kind is the type of value expected for a field key
+type Kind string
+
+// Field kinds, KindAny accepts any value
+const (
+	KindAny      Kind = "any"
+	KindString   Kind = "string"
+	KindInt      Kind = "int"
+	KindFloat    Kind = "float"
+	KindBool     Kind = "bool"
+	KindDuration Kind = "duration"
+	KindTime     Kind = "time"
+	KindBinary   Kind = "binary"
+	KindObject   Kind = "object"
+	KindArray    Kind = "array"
+	KindError    Kind = "error"
+)
+
+// KeySpec describes a declared field key
+type KeySpec struct {
+	Key         string `json:"key"`
+	Kind        Kind   `json:"kind"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema is a registry of field keys and the kind of value each holds, so the same concept is logged under
+// the same key across services.  Keys are checked by loggers configured with logger.Logger.SetSchema.
+type Schema struct {
+	mu   sync.RWMutex
+	keys map[string]KeySpec
+}
+
+// NewSchema creates an empty schema
+func NewSchema() *Schema {
+	return &Schema{keys: make(map[string]KeySpec)}
+}
+
+// DefaultSchema holds the keys of the fields produced by this package
+var DefaultSchema = NewSchema()
+
+func init() {
+	for _, spec := range []KeySpec{
+		{"ip", KindString, "client IP address"},
+		{"user-agent", KindString, "client user agent"},
+		{"url", KindString, "request URL"},
+		{"port", KindAny, "network port, as a number or string"},
+		{"method", KindString, "HTTP method"},
+		{"error", KindError, "error message"},
+		{"error.chain", KindArray, "types and messages of wrapped errors"},
+		{"type", KindString, "type name, see InterfaceType"},
+		{"request-id", KindString, "request identifier"},
+		{"trace-id", KindString, "distributed trace identifier"},
+		{"user-id", KindString, "user identifier"},
+		{"tenant-id", KindString, "tenant identifier"},
+		{"status", KindInt, "HTTP status code"},
+		{"latency", KindDuration, "time taken to serve a request"},
+		{"bytes-in", KindInt, "bytes received"},
+		{"bytes-out", KindInt, "bytes sent"},
+		{"host", KindString, "host name"},
+		{"path", KindString, "URL path"},
+		{"query", KindString, "sanitised URL query"},
+		{"referer", KindString, "referring URL"},
+		{"content-type", KindString, "content type"},
+	} {
+		DefaultSchema.Declare(spec.Key, spec.Kind, spec.Description)
+	}
+}
+
+// Declare adds a key to the DefaultSchema, see Schema.Declare
+func Declare(key string, kind Kind, description string) {
+	DefaultSchema.Declare(key, kind, description)
+}
+
+// Declare adds a key to the schema.  As with sql.Register, declaring a key twice with different kinds panics.
+func (s *Schema) Declare(key string, kind Kind, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.keys[key]; ok && existing.Kind != kind {
+		panic(fmt.Sprintf("ld: key %q declared as %s and %s", key, existing.Kind, kind))
+	}
+	s.keys[key] = KeySpec{Key: key, Kind: kind, Description: description}
+}
+
+// Lookup returns the declaration for the key.  Keys produced by Prefix, or renamed as duplicates with a
+// "#n" suffix, fall back to the declaration of the unprefixed key.
+func (s *Schema) Lookup(key string) (KeySpec, bool) {
+	if i := strings.LastIndexByte(key, '#'); i > 0 {
+		key = key[:i]
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if spec, ok := s.keys[key]; ok {
+		return spec, true
+	}
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		spec, ok := s.keys[key[i+1:]]
+		return spec, ok
+	}
+	return KeySpec{}, false
+}
+
+// Specs returns the declared keys, ordered by key
+func (s *Schema) Specs() []KeySpec {
+	s.mu.RLock()
+	specs := make([]KeySpec, 0, len(s.keys))
+	for _, spec := range s.keys {
+		specs = append(specs, spec)
+	}
+	s.mu.RUnlock()
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Key < specs[j].Key })
+	return specs
+}
+
+// MarshalJSON implements json.Marshaler, encoding the declared keys ordered by key
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Specs())
+}
+
+// Dump writes the declared keys to w as indented JSON
+func (s *Schema) Dump(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.Specs())
+}
+
+// Violation is a field that does not match the schema
+type Violation struct {
+	Key string
+	// Expected is the declared kind, empty when the key is undeclared
+	Expected Kind
+	Actual   Kind
+}
+
+// Error implements error
+func (v Violation) Error() string {
+	if v.Expected == "" {
+		return fmt.Sprintf("undeclared key %q", v.Key)
+	}
+	return fmt.Sprintf("key %q expects %s, got %s", v.Key, v.Expected, v.Actual)
+}
+
+// Check returns the fields that are undeclared, or hold a value of a different kind to their declaration
+func (s *Schema) Check(fields []zap.Field) []Violation {
+	var violations []Violation
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.SkipType, zapcore.NamespaceType, zapcore.InlineMarshalerType:
+			continue
+		}
+		actual := KindOf(f)
+		spec, ok := s.Lookup(f.Key)
+		switch {
+		case !ok:
+			violations = append(violations, Violation{Key: f.Key, Actual: actual})
+		case spec.Kind != KindAny && actual != KindAny && spec.Kind != actual:
+			violations = append(violations, Violation{Key: f.Key, Expected: spec.Kind, Actual: actual})
+		}
+	}
+	return violations
+}
+
+// KindOf returns the kind of value held by the field, KindAny when it cannot be determined without encoding
+func KindOf(f zap.Field) Kind {
+	switch f.Type {
+	case zapcore.StringType, zapcore.StringerType, zapcore.ByteStringType:
+		return KindString
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return KindInt
+	case zapcore.Float64Type, zapcore.Float32Type:
+		return KindFloat
+	case zapcore.BoolType:
+		return KindBool
+	case zapcore.DurationType:
+		return KindDuration
+	case zapcore.TimeType, zapcore.TimeFullType:
+		return KindTime
+	case zapcore.BinaryType:
+		return KindBinary
+	case zapcore.ObjectMarshalerType:
+		return KindObject
+	case zapcore.ArrayMarshalerType:
+		return KindArray
+	case zapcore.ErrorType:
+		return KindError
+	}
+	return KindAny
+}
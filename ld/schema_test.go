@@ -0,0 +1,94 @@
+package ld
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestSchema_Check(t *testing.T) {
+	s := NewSchema()
+	s.Declare("user-id", KindString, "user identifier")
+	s.Declare("count", KindInt, "")
+	s.Declare("payload", KindAny, "")
+
+	violations := s.Check([]zap.Field{
+		UserID("u1"),
+		Prefix("actor", UserID("u2")),
+		zap.String("user-id#2", "u3"),
+		zap.Int("count", 1),
+		zap.Any("payload", map[string]int{}),
+		zap.Int("user-id", 1),
+		zap.String("uid", "u4"),
+		zap.Skip(),
+	})
+	want := []Violation{
+		{Key: "user-id", Expected: KindString, Actual: KindInt},
+		{Key: "uid", Actual: KindString},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("Check: got %v, want %v", violations, want)
+	}
+	for i := range want {
+		if violations[i] != want[i] {
+			t.Errorf("Check[%d]: got %v, want %v", i, violations[i], want[i])
+		}
+	}
+	if got := want[0].Error(); got != `key "user-id" expects string, got int` {
+		t.Errorf("Violation.Error: got %s", got)
+	}
+	if got := want[1].Error(); got != `undeclared key "uid"` {
+		t.Errorf("Violation.Error: got %s", got)
+	}
+}
+
+func TestSchema_Declare(t *testing.T) {
+	s := NewSchema()
+	s.Declare("key", KindString, "first")
+	s.Declare("key", KindString, "second")
+	if spec, _ := s.Lookup("key"); spec.Description != "second" {
+		t.Errorf("redeclaring with the same kind should update the description, got %v", spec)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("redeclaring with a different kind should panic")
+		}
+	}()
+	s.Declare("key", KindInt, "")
+}
+
+func TestSchema_Dump(t *testing.T) {
+	s := NewSchema()
+	s.Declare("b", KindDuration, "")
+	s.Declare("a", KindBool, "flag")
+
+	buf := &bytes.Buffer{}
+	if err := s.Dump(buf); err != nil {
+		t.Fatal(err)
+	}
+	var specs []KeySpec
+	if err := json.Unmarshal(buf.Bytes(), &specs); err != nil {
+		t.Fatal(err)
+	}
+	want := []KeySpec{{Key: "a", Kind: KindBool, Description: "flag"}, {Key: "b", Kind: KindDuration}}
+	if len(specs) != 2 || specs[0] != want[0] || specs[1] != want[1] {
+		t.Errorf("Dump: got %v, want %v", specs, want)
+	}
+}
+
+func TestDefaultSchema(t *testing.T) {
+	fields := []zap.Field{
+		IP("127.0.0.1"), UserAgent("ua"), URL("/"), Port(80), PortString("80"), Method("GET"),
+		Error(errors.New("e")), ErrorChain("error.chain", errors.New("e")), InterfaceType("handler", t),
+		RequestID("r"), TraceID("t"), UserID("u"), TenantID("t"), StatusCode(200), Latency(time.Second),
+		BytesIn(1), BytesOut(1), Host("h"), Path("/"), Query("a=b"), Referer("r"), ContentType("c"),
+	}
+	if violations := DefaultSchema.Check(fields); len(violations) > 0 {
+		t.Errorf("the fields of this package should be declared, got %v", violations)
+	}
+}
@@ -0,0 +1,58 @@
+package ld
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// RedactedMask is written in place of any secret value
+const RedactedMask = "[REDACTED]"
+
+// RedactedQueryValue replaces sensitive query values, unlike RedactedMask it needs no escaping within a URL
+const RedactedQueryValue = "REDACTED"
+
+// SensitiveKeys are the case-insensitive glob patterns (see path.Match) of keys whose values are secret.
+// They are the default patterns of logger.NewRedactor, and are redacted from query strings by Query, Request and
+// loghttp.Transport, and from headers and bodies by loghttp.Transport.
+var SensitiveKeys = []string{
+	"*password*", "passwd", "*secret*", "*token*", "authorization", "proxy-authorization", "auth",
+	"cookie", "set-cookie", "api-key", "apikey", "api_key", "x-api-key", "key", "sig", "signature",
+}
+
+// IsSensitiveKey reports whether the key matches any of SensitiveKeys
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range SensitiveKeys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretValue wraps a sensitive value so that it is never rendered as-is, whether it is logged directly,
+// formatted with fmt or nested within a struct that is marshalled to JSON.
+type SecretValue struct {
+	value any
+}
+
+// Value returns the wrapped value, allowing a logger to hash it rather than mask it.
+func (s SecretValue) Value() any { return s.value }
+
+// String implements fmt.Stringer
+func (s SecretValue) String() string { return RedactedMask }
+
+// GoString implements fmt.GoStringer so %#v does not expose the value
+func (s SecretValue) GoString() string { return RedactedMask }
+
+// MarshalJSON implements json.Marshaler
+func (s SecretValue) MarshalJSON() ([]byte, error) { return json.Marshal(RedactedMask) }
+
+// NewSecret wraps a value as a SecretValue, for use as a struct field or within zap.Any.
+func NewSecret(value any) SecretValue { return SecretValue{value: value} }
+
+// Secret returns a zap.Field whose value is masked before it reaches any encoder.
+func Secret(key string, value any) zap.Field { return zap.Stringer(key, NewSecret(value)) }
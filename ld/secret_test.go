@@ -0,0 +1,52 @@
+package ld
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSecret(t *testing.T) {
+	f := Secret("password", "hunter2")
+	if f.Key != "password" {
+		t.Errorf("Secret key: got %s, want password", f.Key)
+	}
+	if f.Type != zapcore.StringerType {
+		t.Errorf("Secret type: got %v, want %v", f.Type, zapcore.StringerType)
+	}
+	s, ok := f.Interface.(SecretValue)
+	if !ok {
+		t.Fatalf("Secret interface: got %T, want SecretValue", f.Interface)
+	}
+	if s.Value() != "hunter2" {
+		t.Errorf("Secret value: got %v, want hunter2", s.Value())
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	if enc.Fields["password"] != RedactedMask {
+		t.Errorf("Secret encoded: got %v, want %s", enc.Fields["password"], RedactedMask)
+	}
+}
+
+func TestSecretValueFormatting(t *testing.T) {
+	s := NewSecret("hunter2")
+	for _, got := range []string{fmt.Sprint(s), fmt.Sprintf("%v", s), fmt.Sprintf("%#v", s), s.String()} {
+		if got != RedactedMask {
+			t.Errorf("formatted secret: got %s, want %s", got, RedactedMask)
+		}
+	}
+
+	b, err := json.Marshal(struct {
+		User string      `json:"user"`
+		Pass SecretValue `json:"pass"`
+	}{"bob", s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"user":"bob","pass":"[REDACTED]"}` {
+		t.Errorf("json secret: got %s", b)
+	}
+}
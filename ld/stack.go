@@ -0,0 +1,76 @@
+package ld
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// maxStackDepth is the maximum number of frames captured by WithStack
+const maxStackDepth = 64
+
+// StackTracer is implemented by errors that captured the stack when they were created.
+// Loggers print the stack of the first StackTracer within an error field for Error level entries and above.
+type StackTracer interface {
+	StackTrace() []uintptr
+}
+
+type stackError struct {
+	msg string
+	err error
+	pcs []uintptr
+}
+
+func (e *stackError) Error() string {
+	if e.msg == "" {
+		return e.err.Error()
+	}
+	return e.msg + ": " + e.err.Error()
+}
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// StackTrace implements StackTracer
+func (e *stackError) StackTrace() []uintptr { return e.pcs }
+
+// WithStack annotates err with the stack of the caller, or returns nil if err is nil.
+// Errors that already carry a stack are returned unchanged, so the stack always points at the origin.
+func WithStack(err error) error {
+	if err == nil || HasStack(err) {
+		return err
+	}
+	return &stackError{err: err, pcs: callers()}
+}
+
+// Wrap prefixes err with msg, as fmt.Errorf("msg: %w", err), capturing the stack of the caller if err
+// does not already carry one.  Returns nil if err is nil.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	if HasStack(err) {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return &stackError{msg: msg, err: err, pcs: callers()}
+}
+
+// Errorf formats an error as fmt.Errorf, capturing the stack of the caller
+func Errorf(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if HasStack(err) {
+		return err
+	}
+	return &stackError{err: err, pcs: callers()}
+}
+
+// HasStack reports whether any error within the tree carries a stack
+func HasStack(err error) bool {
+	var st StackTracer
+	return errors.As(err, &st)
+}
+
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers, callers and the exported constructor
+	return pcs[:runtime.Callers(3, pcs)]
+}
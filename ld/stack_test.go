@@ -0,0 +1,74 @@
+package ld
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func originFrame(st StackTracer) runtime.Frame {
+	frame, _ := runtime.CallersFrames(st.StackTrace()).Next()
+	return frame
+}
+
+func createError() error { return WithStack(errors.New("origin")) }
+
+func TestWithStack(t *testing.T) {
+	if WithStack(nil) != nil {
+		t.Errorf("expected nil for nil error")
+	}
+
+	err := createError()
+	var st StackTracer
+	if !errors.As(err, &st) {
+		t.Fatalf("expected error to carry a stack")
+	}
+	if fn := originFrame(st).Function; fn != "github.com/packaged/logger/v3/ld.createError" {
+		t.Errorf("expected stack to start at createError, got %s", fn)
+	}
+	if err.Error() != "origin" {
+		t.Errorf("unexpected message %s", err.Error())
+	}
+
+	if again := WithStack(err); again != err {
+		t.Errorf("expected an error with a stack to be returned unchanged")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil, "msg") != nil {
+		t.Errorf("expected nil for nil error")
+	}
+
+	base := errors.New("base")
+	err := Wrap(base, "loading")
+	if err.Error() != "loading: base" || !errors.Is(err, base) {
+		t.Errorf("unexpected wrapped error %v", err)
+	}
+
+	origin := createError()
+	rewrapped := Wrap(origin, "outer")
+	var st StackTracer
+	errors.As(rewrapped, &st)
+	if fn := originFrame(st).Function; fn != "github.com/packaged/logger/v3/ld.createError" {
+		t.Errorf("expected original stack to be kept, got %s", fn)
+	}
+	if rewrapped.Error() != "outer: origin" {
+		t.Errorf("unexpected message %s", rewrapped.Error())
+	}
+}
+
+func TestErrorf(t *testing.T) {
+	err := Errorf("failed %d", 1)
+	if err.Error() != "failed 1" || !HasStack(err) {
+		t.Errorf("unexpected error %v", err)
+	}
+	origin := createError()
+	if wrapped := Errorf("outer: %w", origin); !errors.Is(wrapped, origin) || fmt.Sprint(wrapped) != "outer: origin" {
+		t.Errorf("unexpected error %v", wrapped)
+	}
+	if HasStack(errors.New("plain")) {
+		t.Errorf("plain errors should not have a stack")
+	}
+}
@@ -0,0 +1,261 @@
+package logger
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// histogramBase is the upper bound of the first histogram bucket
+	histogramBase = time.Microsecond
+	// histogramBucketsPerDoubling sets the precision of percentiles, each bucket is ~19% wider than the last
+	histogramBucketsPerDoubling = 4
+	// histogramBuckets covers durations up to ~2^35µs, around ten hours
+	histogramBuckets = 35 * histogramBucketsPerDoubling
+)
+
+// aggregators holds the aggregated durations of each TimedLogConfig with durations awaiting a summary.
+// An aggregator is removed once its summaries have been written, so replaced configs do not accumulate.
+var aggregators sync.Map
+
+type aggregator struct {
+	mu      sync.Mutex
+	windows map[string]*histogram
+	// logger writes the summaries due on the clock, it is the logger which last recorded a duration
+	logger  *Logger
+	running bool
+	closed  bool
+}
+
+func (c *TimedLogConfig) aggregator() *aggregator {
+	if agg, ok := aggregators.Load(c); ok {
+		return agg.(*aggregator)
+	}
+	agg, _ := aggregators.LoadOrStore(c, &aggregator{windows: make(map[string]*histogram)})
+	return agg.(*aggregator)
+}
+
+// timedLevel returns the level to write the completed timed log at.
+// When the config aggregates, the duration is recorded, any summary due is written, and only entries at
+// Warn or above, such as outliers beyond WarnDuration and failures, are written individually.
+func (l *Logger) timedLevel(tl *TimedLog) (zapcore.Level, bool) {
+	duration, err := tl.result()
+	lvl, ok := tl.thresholds().LevelFor(duration, err)
+	if tl.config.AggregateInterval <= 0 {
+		return lvl, ok
+	}
+	now := tl.config.clock().Now()
+	for {
+		summary, recorded := tl.config.aggregator().record(l, tl.config, tl.message, duration, now)
+		if !recorded {
+			// the aggregator was removed as it was being loaded
+			continue
+		}
+		if summary != nil {
+			l.writeSummary(summary)
+		}
+		return lvl, ok && lvl >= zapcore.WarnLevel
+	}
+}
+
+// Flush writes a summary for every message with durations aggregated since the last summary
+func (c *TimedLogConfig) Flush(l *Logger) {
+	if l == nil {
+		return
+	}
+	agg, ok := aggregators.Load(c)
+	if !ok {
+		return
+	}
+	for _, summary := range agg.(*aggregator).flush(c.clock().Now(), 0) {
+		l.writeSummary(summary)
+	}
+}
+
+func (l *Logger) writeSummary(s *timedSummary) {
+	l.logWith(zapcore.InfoLevel, s.message, []zap.Field{zap.Object("timings", s)}, func(ent *zapcore.Entry) {
+		// summaries describe many call sites
+		ent.Caller = zapcore.EntryCaller{}
+	})
+}
+
+// record adds the duration to the window for the message, returning the summary of the previous window
+// when it is older than the interval.  It reports false, recording nothing, when the aggregator is closed.
+func (a *aggregator) record(l *Logger, c *TimedLogConfig, message string, d time.Duration, now time.Time) (*timedSummary, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil, false
+	}
+
+	var summary *timedSummary
+	h := a.windows[message]
+	if h != nil && now.Sub(h.start) >= c.AggregateInterval {
+		summary = h.summary(message, now)
+		h = nil
+	}
+	if h == nil {
+		h = &histogram{start: now}
+		a.windows[message] = h
+	}
+	h.add(d)
+	a.logger = l
+	if !a.running {
+		a.running = true
+		go a.run(c)
+	}
+	return summary, true
+}
+
+// run writes the summary of each window once it is older than the interval, timed by the config's clock.
+// When no windows remain the aggregator is closed and removed.
+func (a *aggregator) run(c *TimedLogConfig) {
+	clock, interval := c.clock(), c.AggregateInterval
+	for {
+		due, ok := a.nextDue(c, interval)
+		if !ok {
+			return
+		}
+		timer := clock.NewTimer(due.Sub(clock.Now()))
+		now := <-timer.C()
+		a.mu.Lock()
+		l := a.logger
+		a.mu.Unlock()
+		for _, summary := range a.flush(now, interval) {
+			l.writeSummary(summary)
+		}
+	}
+}
+
+// nextDue returns when the oldest window is due a summary, or closes the aggregator if there are none
+func (a *aggregator) nextDue(c *TimedLogConfig, interval time.Duration) (time.Time, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var oldest time.Time
+	for _, h := range a.windows {
+		if oldest.IsZero() || h.start.Before(oldest) {
+			oldest = h.start
+		}
+	}
+	if len(a.windows) == 0 {
+		a.closed = true
+		aggregators.CompareAndDelete(c, a)
+		return time.Time{}, false
+	}
+	return oldest.Add(interval), true
+}
+
+// flush returns the summaries of the windows which started at least age before now, removing them
+func (a *aggregator) flush(now time.Time, age time.Duration) []*timedSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	summaries := make([]*timedSummary, 0, len(a.windows))
+	for message, h := range a.windows {
+		if now.Sub(h.start) < age {
+			continue
+		}
+		summaries = append(summaries, h.summary(message, now))
+		delete(a.windows, message)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].message < summaries[j].message })
+	return summaries
+}
+
+// histogram counts durations in exponentially sized buckets
+type histogram struct {
+	start    time.Time
+	count    int64
+	sum      time.Duration
+	min, max time.Duration
+	buckets  [histogramBuckets]int64
+}
+
+func (h *histogram) add(d time.Duration) {
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+	h.buckets[bucketIndex(d)]++
+}
+
+func bucketIndex(d time.Duration) int {
+	if d <= histogramBase {
+		return 0
+	}
+	i := int(math.Ceil(math.Log2(float64(d)/float64(histogramBase)) * histogramBucketsPerDoubling))
+	if i >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return i
+}
+
+func bucketBound(i int) time.Duration {
+	return time.Duration(float64(histogramBase) * math.Exp2(float64(i)/histogramBucketsPerDoubling))
+}
+
+// percentile returns the upper bound of the bucket holding the pth percentile, within the observed range
+func (h *histogram) percentile(p float64) time.Duration {
+	target := int64(math.Ceil(p * float64(h.count)))
+	var seen int64
+	for i, n := range h.buckets {
+		seen += n
+		if seen >= target {
+			bound := bucketBound(i)
+			if bound > h.max {
+				return h.max
+			}
+			if bound < h.min {
+				return h.min
+			}
+			return bound
+		}
+	}
+	return h.max
+}
+
+func (h *histogram) summary(message string, now time.Time) *timedSummary {
+	return &timedSummary{
+		message:  message,
+		interval: now.Sub(h.start),
+		count:    h.count,
+		min:      h.min,
+		max:      h.max,
+		mean:     h.sum / time.Duration(h.count),
+		p50:      h.percentile(0.50),
+		p95:      h.percentile(0.95),
+		p99:      h.percentile(0.99),
+	}
+}
+
+// timedSummary is the distribution of the durations aggregated for a message
+type timedSummary struct {
+	message        string
+	interval       time.Duration
+	count          int64
+	min, max, mean time.Duration
+	p50, p95, p99  time.Duration
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler
+func (s *timedSummary) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt64("count", s.count)
+	enc.AddDuration("interval", s.interval)
+	enc.AddDuration("min", s.min)
+	enc.AddDuration("max", s.max)
+	enc.AddDuration("mean", s.mean)
+	enc.AddDuration("p50", s.p50)
+	enc.AddDuration("p95", s.p95)
+	enc.AddDuration("p99", s.p99)
+	return nil
+}
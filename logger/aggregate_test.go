@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func completedLog(cfg *TimedLogConfig, message string, d time.Duration) *TimedLog {
+	return &TimedLog{config: cfg, message: message, start: time.Now(), duration: d, complete: true}
+}
+
+func TestTimedLogAggregate(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	cfg := &TimedLogConfig{AggregateInterval: time.Hour, WarnDuration: 50 * time.Millisecond, DebugDuration: time.Nanosecond}
+
+	for i := 1; i <= 100; i++ {
+		l.TimedLog(completedLog(cfg, "lookup", time.Duration(i)*time.Millisecond))
+	}
+	failed := completedLog(cfg, "other", time.Microsecond)
+	failed.err = errors.New("miss")
+	l.TimedLog(failed)
+
+	outliers := observedLogs.TakeAll()
+	assert.Len(t, outliers, 52, "only outliers and failures should be written individually")
+	for _, entry := range outliers {
+		assert.GreaterOrEqual(t, entry.Level, zapcore.WarnLevel)
+	}
+
+	cfg.Flush(l)
+	summaries := observedLogs.TakeAll()
+	if !assert.Len(t, summaries, 2) {
+		return
+	}
+	assert.Equal(t, "lookup", summaries[0].Message)
+	assert.Equal(t, zapcore.InfoLevel, summaries[0].Level)
+	timings := summaries[0].ContextMap()["timings"].(map[string]any)
+	assert.Equal(t, int64(100), timings["count"])
+	assert.Equal(t, time.Millisecond, timings["min"])
+	assert.Equal(t, 100*time.Millisecond, timings["max"])
+	assert.Equal(t, 50500*time.Microsecond, timings["mean"])
+	assert.InDelta(t, 50*time.Millisecond, timings["p50"], float64(10*time.Millisecond))
+	assert.InDelta(t, 95*time.Millisecond, timings["p95"], float64(10*time.Millisecond))
+	assert.InDelta(t, 99*time.Millisecond, timings["p99"], float64(10*time.Millisecond))
+	assert.Equal(t, "other", summaries[1].Message)
+
+	cfg.Flush(l)
+	assert.Equal(t, 0, observedLogs.Len(), "flushing should reset the aggregated durations")
+}
+
+func TestTimedLogAggregate_Interval(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	clock := NewManualClock(time.Now())
+	cfg := &TimedLogConfig{AggregateInterval: time.Millisecond, DebugDuration: time.Nanosecond, Clock: clock}
+
+	l.TimedLog(completedLog(cfg, "tick", time.Second))
+	assert.Equal(t, 0, observedLogs.Len())
+	clock.Advance(2 * time.Millisecond)
+	l.TimedLog(completedLog(cfg, "tick", time.Second))
+
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 1 }, time.Second, time.Millisecond)
+	summaries := observedLogs.TakeAll()
+	if assert.Len(t, summaries, 1, "a summary should be written once the interval has passed") {
+		timings := summaries[0].ContextMap()["timings"].(map[string]any)
+		assert.Equal(t, int64(1), timings["count"])
+		assert.Equal(t, 2*time.Millisecond, timings["interval"])
+	}
+}
+
+func TestTimedLogAggregate_Periodic(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	clock := NewManualClock(time.Now())
+	cfg := &TimedLogConfig{AggregateInterval: time.Minute, DebugDuration: time.Nanosecond, Clock: clock}
+
+	l.TimedLog(completedLog(cfg, "burst", time.Millisecond))
+	clock.Advance(30 * time.Second)
+	l.TimedLog(completedLog(cfg, "burst", 3*time.Millisecond))
+	l.TimedLog(completedLog(cfg, "later", time.Millisecond))
+	assert.Equal(t, 0, observedLogs.Len())
+
+	clock.Advance(30 * time.Second)
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 1 }, time.Second, time.Millisecond,
+		"a summary should be written once the interval has passed, without another entry")
+	summary := observedLogs.TakeAll()[0]
+	assert.Equal(t, "burst", summary.Message)
+	assert.Equal(t, int64(2), summary.ContextMap()["timings"].(map[string]any)["count"])
+
+	clock.Advance(30 * time.Second)
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "later", observedLogs.TakeAll()[0].Message)
+
+	assert.Eventually(t, func() bool {
+		_, ok := aggregators.Load(cfg)
+		return !ok
+	}, time.Second, time.Millisecond, "an aggregator with nothing left to summarise should be removed")
+
+	l.TimedLog(completedLog(cfg, "burst", time.Millisecond))
+	cfg.Flush(l)
+	assert.Equal(t, 1, observedLogs.Len(), "a removed aggregator should be replaced on the next entry")
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	h := &histogram{}
+	h.add(3 * time.Second)
+	assert.Equal(t, 3*time.Second, h.percentile(0.5), "percentiles should stay within the observed range")
+	assert.Equal(t, 0, bucketIndex(0))
+	assert.Equal(t, histogramBuckets-1, bucketIndex(1000*time.Hour))
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for timed logs, see TimedLogConfig.Clock
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event created by a Clock, see time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// SystemClock is the Clock backed by the time package, used when TimedLogConfig.Clock is nil
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time { return t.timer.C }
+
+func (t systemTimer) Stop() bool { return t.timer.Stop() }
+
+// ManualClock is a Clock which only moves when advanced, so tests can cross thresholds without sleeping.
+// The zero value starts at the zero time.
+type ManualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// NewManualClock returns a ManualClock starting at now
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward, firing any timers which are due
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if !t.fire(c.now) {
+			pending = append(pending, t)
+		}
+	}
+	c.timers = pending
+}
+
+// NewTimer returns a timer firing once the clock has been advanced by d, or immediately if d is not positive
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &manualTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if !t.fire(c.now) {
+		c.timers = append(c.timers, t)
+	}
+	return t
+}
+
+type manualTimer struct {
+	clock *ManualClock
+	at    time.Time
+	c     chan time.Time
+}
+
+// fire sends the time on the channel if the timer is due, reporting whether it was sent
+func (t *manualTimer) fire(now time.Time) bool {
+	if now.Before(t.at) {
+		return false
+	}
+	t.c <- now
+	return true
+}
+
+func (t *manualTimer) C() <-chan time.Time { return t.c }
+
+// Stop prevents the timer from firing, reporting whether it was pending
+func (t *manualTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
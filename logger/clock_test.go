@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+
+	due := clock.NewTimer(time.Second)
+	stopped := clock.NewTimer(time.Second)
+	later := clock.NewTimer(time.Minute)
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop(), "a stopped timer is no longer pending")
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), clock.Now())
+	select {
+	case fired := <-due.C():
+		assert.Equal(t, start.Add(time.Second), fired)
+	default:
+		t.Error("expected the due timer to fire")
+	}
+	assert.Empty(t, stopped.C())
+	assert.Empty(t, later.C())
+	assert.False(t, due.Stop(), "a fired timer is no longer pending")
+
+	assert.Len(t, clock.NewTimer(0).C(), 1, "timers which are already due should fire immediately")
+}
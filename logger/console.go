@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"strings"
+
+	"github.com/packaged/logger/v3/ld"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// isConsole reports whether the config writes human-readable console output
+func isConsole(cfg zap.Config) bool {
+	return strings.TrimSuffix(cfg.Encoding, nestedEncodingSuffix) == "console"
+}
+
+// humanize renders values intended for people reading console output, such as ld.Bytes in binary units.
+// The provided slice is not modified.
+func humanize(fields []zap.Field) []zap.Field {
+	var out []zap.Field
+	for i, f := range fields {
+		size, ok := f.Interface.(ld.ByteSize)
+		if !ok || f.Type != zapcore.Int64Type {
+			continue
+		}
+		if out == nil {
+			out = make([]zap.Field, len(fields))
+			copy(out, fields)
+		}
+		out[i] = zap.Stringer(f.Key, size)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
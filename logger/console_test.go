@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/packaged/environment/environment"
+	"github.com/packaged/logger/v3/ld"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHumanize(t *testing.T) {
+	fields := []zap.Field{ld.BytesOut(1536), zap.Int64("count", 1536)}
+	humanized := humanize(fields)
+	assert.Equal(t, zap.Stringer("bytes-out", ld.ByteSize(1536)), humanized[0])
+	assert.Equal(t, fields[1], humanized[1], "plain integers should not be changed")
+	assert.Equal(t, ld.BytesOut(1536), fields[0], "the provided slice should not be modified")
+}
+
+func TestLogger_ConsoleBytes(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := &Logger{env: environment.UnitTest, zapper: zap.New(core), console: true}
+	l.Info("sent", ld.BytesOut(2048))
+	assert.Equal(t, "2 KiB", logs.All()[0].ContextMap()["bytes-out"])
+
+	l.console = false
+	l.Info("sent", ld.BytesOut(2048))
+	assert.Equal(t, int64(2048), logs.All()[1].ContextMap()["bytes-out"])
+}
+
+func TestIsConsole(t *testing.T) {
+	assert.True(t, isConsole(zap.Config{Encoding: "console"}))
+	assert.True(t, isConsole(zap.Config{Encoding: "console+nested"}))
+	assert.False(t, isConsole(zap.Config{Encoding: "json"}))
+}
@@ -4,6 +4,8 @@ import "context"
 
 type ctxKey struct{}
 
+type timedLogConfigKey struct{}
+
 // NewContext returns a new context with the logger attached.
 func NewContext(ctx context.Context, l *Logger) context.Context {
 	return context.WithValue(ctx, ctxKey{}, l)
@@ -17,3 +19,17 @@ func FromContext(ctx context.Context) *Logger {
 	}
 	return I()
 }
+
+// WithTimedLogConfig returns a new context with the timed log config attached, for use by StartTimer.
+func WithTimedLogConfig(ctx context.Context, cfg *TimedLogConfig) context.Context {
+	return context.WithValue(ctx, timedLogConfigKey{}, cfg)
+}
+
+// TimedLogConfigFromContext retrieves the timed log config from the context.
+// Returns DefaultTimedLogConfig if none is set on the context.
+func TimedLogConfigFromContext(ctx context.Context) *TimedLogConfig {
+	if cfg, ok := ctx.Value(timedLogConfigKey{}).(*TimedLogConfig); ok && cfg != nil {
+		return cfg
+	}
+	return DefaultTimedLogConfig()
+}
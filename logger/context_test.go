@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
@@ -56,3 +57,10 @@ func TestFromContext_NilValue_ReturnsGlobal(t *testing.T) {
 	got := FromContext(ctx)
 	assert.Equal(t, gLog, got)
 }
+
+func TestTimedLogConfigFromContext(t *testing.T) {
+	assert.Equal(t, DefaultTimedLogConfig(), TimedLogConfigFromContext(context.Background()))
+
+	cfg := &TimedLogConfig{WarnDuration: time.Second}
+	assert.Equal(t, cfg, TimedLogConfigFromContext(WithTimedLogConfig(context.Background(), cfg)))
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// DuplicatePolicy determines how a call-site field sharing a key with a common field is resolved
+type DuplicatePolicy int
+
+const (
+	// CallSiteWins keeps the call-site field and discards the common field
+	CallSiteWins DuplicatePolicy = iota
+	// CommonWins keeps the common field and discards the call-site field
+	CommonWins
+	// RenameDuplicates keeps both fields, renaming the call-site key to key#2 (or #3 etc. if taken)
+	RenameDuplicates
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case CallSiteWins:
+		return "call-site-wins"
+	case CommonWins:
+		return "common-wins"
+	case RenameDuplicates:
+		return "rename"
+	}
+	return "unknown"
+}
+
+// SetDuplicatePolicy sets how key collisions between common and call-site fields are resolved.
+// Collisions are reported as a warning when the logger environment is dev or test.
+func (l *Logger) SetDuplicatePolicy(policy DuplicatePolicy) {
+	l.duplicates = policy
+}
+
+// resolveDuplicates merges common and call-site fields, applying the duplicate policy to colliding keys
+func (l *Logger) resolveDuplicates(fields []zap.Field) ([]zap.Field, []string) {
+	var collisions []string
+	var dropCommon map[string]bool
+
+	siteFields := make([]zap.Field, 0, len(fields))
+	for _, f := range fields {
+		if f.Key == "" || !hasKey(l.common, f.Key) {
+			siteFields = append(siteFields, f)
+			continue
+		}
+		collisions = append(collisions, f.Key)
+		switch l.duplicates {
+		case CommonWins:
+			// the call-site field is discarded
+		case RenameDuplicates:
+			f.Key = uniqueKey(f.Key, l.common, fields, siteFields)
+			siteFields = append(siteFields, f)
+		default:
+			if dropCommon == nil {
+				dropCommon = make(map[string]bool)
+			}
+			dropCommon[f.Key] = true
+			siteFields = append(siteFields, f)
+		}
+	}
+
+	all := make([]zap.Field, 0, len(l.common)+len(siteFields))
+	for _, f := range l.common {
+		if !dropCommon[f.Key] {
+			all = append(all, f)
+		}
+	}
+	all = append(all, siteFields...)
+	return all, collisions
+}
+
+func hasKey(fields []zap.Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueKey returns key#n for the lowest n >= 2 not already in use
+func uniqueKey(key string, fieldSets ...[]zap.Field) string {
+	for n := 2; ; n++ {
+		candidate := key + "#" + strconv.Itoa(n)
+		inUse := false
+		for _, set := range fieldSets {
+			if hasKey(set, candidate) {
+				inUse = true
+				break
+			}
+		}
+		if !inUse {
+			return candidate
+		}
+	}
+}
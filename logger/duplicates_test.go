@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/packaged/environment/environment"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_DuplicatePolicy(t *testing.T) {
+	tests := []struct {
+		policy DuplicatePolicy
+		keys   []string
+		values []string
+	}{
+		{CallSiteWins, []string{"service", "user-id"}, []string{"api", "site"}},
+		{CommonWins, []string{"user-id", "service"}, []string{"common", "api"}},
+		{RenameDuplicates, []string{"user-id", "service", "user-id#2"}, []string{"common", "api", "site"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+			l := &Logger{zapper: zap.New(observedZapCore), env: environment.Production}
+			l.AddCommon(zap.String("user-id", "common"), zap.String("service", "api"))
+			l.SetDuplicatePolicy(test.policy)
+
+			l.Info("test", zap.String("user-id", "site"))
+			logs := observedLogs.TakeAll()
+			assert.Len(t, logs, 1)
+			assert.Len(t, logs[0].Context, len(test.keys))
+			for i, f := range logs[0].Context {
+				assert.Equal(t, test.keys[i], f.Key)
+				assert.Equal(t, test.values[i], f.String)
+			}
+		})
+	}
+}
+
+func TestLogger_DuplicateRenameTaken(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore), env: environment.Production}
+	l.AddCommon(zap.String("id", "1"), zap.String("id#2", "2"))
+	l.SetDuplicatePolicy(RenameDuplicates)
+
+	l.Info("test", zap.String("id", "3"), zap.String("id", "4"))
+	logs := observedLogs.TakeAll()
+	var keys []string
+	for _, f := range logs[0].Context {
+		keys = append(keys, f.Key)
+	}
+	assert.Equal(t, []string{"id", "id#2", "id#3", "id#4"}, keys)
+}
+
+func TestLogger_DuplicateWarning(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore), env: environment.Development}
+	l.AddCommon(zap.String("user-id", "common"))
+
+	l.Info("test", zap.String("other", "x"))
+	assert.Equal(t, 1, observedLogs.Len())
+	observedLogs.TakeAll()
+
+	l.Info("test", zap.String("user-id", "site"))
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 2)
+	assert.Equal(t, "test", logs[0].Message)
+	assert.Equal(t, "duplicate log field keys", logs[1].Message)
+	assert.Equal(t, []interface{}{"user-id"}, logs[1].ContextMap()["keys"])
+}
@@ -6,4 +6,8 @@ import "github.com/packaged/environment/environment"
 const (
 	// BinaryDebugLogging is the environment variable that can be used to enable debug logging in a binary
 	BinaryDebugLogging environment.Name = "PACKAGED__DEBUG_LOG"
+
+	// TimedLogPrefix prefixes the environment variables configuring named timed logs, such as
+	// PACKAGED__TIMED_LOG_db.query=warn:2s,error:10s, see TimedLogRegistry.LoadEnv
+	TimedLogPrefix environment.Name = "PACKAGED__TIMED_LOG_"
 )
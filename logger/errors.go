@@ -0,0 +1,33 @@
+package logger
+
+import (
+	"github.com/packaged/logger/v3/ld"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ErrorChainSuffix is appended to the key of an error field to hold its structured chain
+const ErrorChainSuffix = ".chain"
+
+// expandErrors appends the structured chain of any wrapped or joined error fields, and the fields
+// provided by errors implementing ld.LogFielder
+func expandErrors(fields []zap.Field) []zap.Field {
+	var extra []zap.Field
+	for _, f := range fields {
+		if f.Type != zapcore.ErrorType {
+			continue
+		}
+		err, ok := f.Interface.(error)
+		if !ok || err == nil {
+			continue
+		}
+		if ld.IsWrapped(err) {
+			extra = append(extra, ld.ErrorChain(f.Key+ErrorChainSuffix, err))
+		}
+		extra = append(extra, ld.ErrorFields(err)...)
+	}
+	if len(extra) == 0 {
+		return fields
+	}
+	return append(fields, extra...)
+}
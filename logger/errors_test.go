@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type orderError struct {
+	orderID string
+}
+
+func (e *orderError) Error() string { return "order " + e.orderID + " rejected" }
+
+func (e *orderError) LogFields() []zap.Field { return []zap.Field{zap.String("order-id", e.orderID)} }
+
+func TestLogger_RichErrors(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+
+	err := fmt.Errorf("checkout: %w", &orderError{orderID: "abc"})
+	l.WarnIf(err, "failed")
+	l.ErrorIf(errors.New("plain"), "plain")
+
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 2)
+
+	ctx := logs[0].ContextMap()
+	assert.Equal(t, "checkout: order abc rejected", ctx["error"])
+	assert.Equal(t, "abc", ctx["order-id"])
+	chain := ctx["error"+ErrorChainSuffix].([]interface{})
+	assert.Len(t, chain, 2)
+	assert.Equal(t, "*logger.orderError", chain[1].(map[string]interface{})["type"])
+
+	ctx = logs[1].ContextMap()
+	assert.Equal(t, "plain", ctx["error"])
+	assert.NotContains(t, ctx, "error"+ErrorChainSuffix)
+}
+
+func TestLogger_RichErrorsScrubbed(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	l.SetScrubber(NewScrubber())
+
+	l.ErrorIf(fmt.Errorf("lookup: %w", errors.New("no user bob@example.com")), "failed")
+	ctx := observedLogs.TakeAll()[0].ContextMap()
+	chain := ctx["error"+ErrorChainSuffix].([]interface{})
+	assert.Equal(t, "no user [REDACTED:email]", chain[1].(map[string]interface{})["message"])
+}
+
+func TestLogger_RichErrorsDuplicateCommon(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	l.AddCommon(zap.String("order-id", "common"))
+
+	l.ErrorIf(&orderError{orderID: "abc"}, "failed")
+
+	logs := observedLogs.FilterMessage("failed").All()
+	if assert.Len(t, logs, 1) {
+		var orderIDs []string
+		for _, f := range logs[0].Context {
+			if f.Key == "order-id" {
+				orderIDs = append(orderIDs, f.String)
+			}
+		}
+		assert.Equal(t, []string{"abc"}, orderIDs, "error fields should replace common fields with the same key")
+	}
+}
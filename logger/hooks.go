@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry is a log entry as presented to a Hook, before it is encoded.
+// Changes to Level and Caller are ignored.
+type Entry struct {
+	Level   zapcore.Level
+	Message string
+	Caller  zapcore.EntryCaller
+	Fields  []zap.Field
+}
+
+// Hook is executed for every entry written by a Logger, including TimedLog and the *If helpers.
+// Hooks run after common fields have been added and before redaction, so any fields they add are redacted.
+// A hook may modify the message and fields, or return false to drop the entry.
+// DPanic, Panic and Fatal entries cannot be dropped.
+type Hook interface {
+	Process(e *Entry) bool
+}
+
+// HookFunc adapts a function to the Hook interface
+type HookFunc func(e *Entry) bool
+
+// Process implements Hook
+func (f HookFunc) Process(e *Entry) bool { return f(e) }
+
+// AddHook adds hooks to the logger, executed in the order they are added
+func (l *Logger) AddHook(hooks ...Hook) {
+	// clip so clones sharing the backing array are not affected
+	l.hooks = append(l.hooks[:len(l.hooks):len(l.hooks)], hooks...)
+}
+
+// runHooks executes the hooks against the entry, returning false if the entry should be dropped
+func (l *Logger) runHooks(ce *zapcore.CheckedEntry, fields []zap.Field) ([]zap.Field, bool) {
+	e := &Entry{Level: ce.Entry.Level, Message: ce.Entry.Message, Caller: ce.Entry.Caller, Fields: fields}
+	keep := true
+	for _, h := range l.hooks {
+		if !h.Process(e) {
+			keep = false
+			break
+		}
+	}
+	ce.Entry.Message = e.Message
+	return e.Fields, keep || ce.Entry.Level >= zapcore.DPanicLevel
+}
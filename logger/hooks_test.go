@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_Hooks(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore, zap.AddCaller(), zap.AddCallerSkip(2))}
+	l.SetRedactor(NewRedactor("secret"))
+
+	var seen []*Entry
+	l.AddHook(HookFunc(func(e *Entry) bool {
+		seen = append(seen, &Entry{Level: e.Level, Message: e.Message, Caller: e.Caller, Fields: e.Fields})
+		e.Message = "[hooked] " + e.Message
+		e.Fields = append(e.Fields, zap.String("secret", "added"), zap.String("region", "eu"))
+		return true
+	}))
+	l.AddHook(HookFunc(func(e *Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == "drop" {
+				return false
+			}
+		}
+		return true
+	}))
+
+	l.Info("info", zap.Int("n", 1))
+	l.WarnIf(errors.New("failed"), "warn")
+	l.Debug("dropped", zap.Bool("drop", true))
+	l.TimedLog((&TimedLogConfig{DebugDuration: time.Nanosecond}).NewLog("timed"))
+
+	logs := observedLogs.TakeAll()
+	assert.Len(t, seen, 4)
+	assert.Equal(t, zapcore.InfoLevel, seen[0].Level)
+	assert.True(t, seen[0].Caller.Defined)
+	assert.Contains(t, seen[0].Caller.File, "hooks_test.go")
+	assert.Equal(t, zapcore.WarnLevel, seen[1].Level)
+	assert.Equal(t, "error", seen[1].Fields[0].Key)
+
+	assert.Len(t, logs, 3)
+	assert.Equal(t, "[hooked] info", logs[0].Message)
+	assert.Equal(t, "[hooked] warn", logs[1].Message)
+	assert.Equal(t, "[hooked] timed", logs[2].Message)
+	for _, entry := range logs {
+		ctx := entry.ContextMap()
+		assert.Equal(t, "eu", ctx["region"])
+		assert.Equal(t, "[REDACTED]", ctx["secret"])
+	}
+}
+
+func TestLogger_HookCannotDropPanic(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	l.AddHook(HookFunc(func(e *Entry) bool { return false }))
+
+	assert.Panics(t, func() { l.Panic("panic") })
+	assert.Equal(t, 1, observedLogs.Len())
+
+	dev := &Logger{zapper: zap.New(observedZapCore, zap.Development())}
+	dev.AddHook(HookFunc(func(e *Entry) bool { return false }))
+	assert.Panics(t, func() { dev.DPanic("dpanic") }, "a dropped DPanic entry should still panic in development")
+	assert.Equal(t, 2, observedLogs.Len())
+}
+
+func TestLogger_AddHookClone(t *testing.T) {
+	l := &Logger{zapper: zap.NewNop()}
+	l.AddHook(HookFunc(func(e *Entry) bool { return true }))
+	c1 := l.Clone()
+	c2 := l.Clone()
+	c1.AddHook(HookFunc(func(e *Entry) bool { return false }))
+	c2.AddHook(HookFunc(func(e *Entry) bool { return true }))
+	assert.Len(t, l.hooks, 1)
+	assert.False(t, c1.hooks[1].Process(&Entry{}))
+	assert.True(t, c2.hooks[1].Process(&Entry{}))
+}
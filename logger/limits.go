@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TruncatedMarker is appended to any value shortened to fit within Limits
+const TruncatedMarker = "...(truncated)"
+
+// TruncatedKey is the field added to entries that have been shortened to fit within Limits
+const TruncatedKey = "truncated"
+
+// Limits caps the size of log entries.  Entries exceeding a limit are truncated rather than dropped,
+// and have a TruncatedKey field added.  Zero values are unlimited.
+type Limits struct {
+	// MaxMessageLength is the maximum length of the message in bytes
+	MaxMessageLength int
+	// MaxStringLength is the maximum length of each string field in bytes
+	MaxStringLength int
+	// MaxFields is the maximum number of fields, including common fields
+	MaxFields int
+	// MaxEntrySize is the maximum size of the encoded entry in bytes
+	MaxEntrySize int
+}
+
+// GoogleLimits keeps entries within the 256KB Cloud Logging entry limit, leaving headroom for metadata
+var GoogleLimits = Limits{MaxEntrySize: 250 * 1024}
+
+// SetLimits sets the size limits applied to every entry
+func (l *Logger) SetLimits(limits Limits) {
+	l.limits = limits
+}
+
+// newSizer returns an encoder matching the config, used to measure encoded entries
+func newSizer(cfg zap.Config) zapcore.Encoder {
+	var enc zapcore.Encoder
+	if isConsole(cfg) {
+		enc = zapcore.NewConsoleEncoder(cfg.EncoderConfig)
+	} else {
+		enc = zapcore.NewJSONEncoder(cfg.EncoderConfig)
+	}
+	if strings.HasSuffix(cfg.Encoding, nestedEncodingSuffix) {
+		enc = &nestedEncoder{Encoder: enc}
+	}
+	return enc
+}
+
+var defaultSizer = zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+
+// encodedSize returns the size in bytes of the encoded entry
+func (l *Logger) encodedSize(ent zapcore.Entry, fields []zap.Field) int {
+	sizer := l.sizer
+	if sizer == nil {
+		sizer = defaultSizer
+	}
+	buf, err := sizer.EncodeEntry(ent, fields)
+	if err != nil {
+		return 0
+	}
+	defer buf.Free()
+	return buf.Len()
+}
+
+// applyLimits truncates the entry message and fields to fit within the configured limits
+func (l *Logger) applyLimits(ce *zapcore.CheckedEntry, fields []zap.Field) []zap.Field {
+	lim := l.limits
+	truncated := false
+
+	if lim.MaxMessageLength > 0 && len(ce.Entry.Message) > lim.MaxMessageLength {
+		ce.Entry.Message = truncate(ce.Entry.Message, lim.MaxMessageLength)
+		truncated = true
+	}
+
+	if lim.MaxStringLength > 0 {
+		copied := false
+		for i, f := range fields {
+			if f.Type == zapcore.StringType && len(f.String) > lim.MaxStringLength {
+				if !copied {
+					// copy before modifying, the slice may belong to the caller
+					fields = append([]zap.Field(nil), fields...)
+					copied = true
+				}
+				fields[i].String = truncate(f.String, lim.MaxStringLength)
+				truncated = true
+			}
+		}
+	}
+
+	if lim.MaxFields > 0 && len(fields) > lim.MaxFields {
+		// leave room for the truncated field
+		keep := lim.MaxFields - 1
+		fields = fields[:keep:keep]
+		truncated = true
+	}
+
+	if truncated {
+		fields = append(fields, zap.Bool(TruncatedKey, true))
+	}
+
+	if lim.MaxEntrySize > 0 {
+		fields = l.fitEntry(ce, fields, truncated)
+	}
+	return fields
+}
+
+// fitEntry replaces the largest fields with a size placeholder, then shortens the message, until the
+// encoded entry fits within MaxEntrySize
+func (l *Logger) fitEntry(ce *zapcore.CheckedEntry, fields []zap.Field, truncated bool) []zap.Field {
+	limit := l.limits.MaxEntrySize
+	size := l.encodedSize(ce.Entry, fields)
+	if size <= limit {
+		return fields
+	}
+
+	base := l.encodedSize(zapcore.Entry{}, nil)
+	type fieldSize struct {
+		index int
+		size  int
+	}
+	sizes := make([]fieldSize, 0, len(fields))
+	for i, f := range fields {
+		sizes = append(sizes, fieldSize{index: i, size: l.encodedSize(zapcore.Entry{}, []zap.Field{f}) - base})
+	}
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].size > sizes[j].size })
+
+	fields = append([]zap.Field(nil), fields...)
+	if !truncated {
+		fields = append(fields, zap.Bool(TruncatedKey, true))
+		size = l.encodedSize(ce.Entry, fields)
+	}
+
+	for _, fs := range sizes {
+		if size <= limit {
+			return fields
+		}
+		f := fields[fs.index]
+		if f.Key == TruncatedKey || f.Type == zapcore.SkipType || fs.size <= len(TruncatedMarker)*2 {
+			continue
+		}
+		fields[fs.index] = zap.String(f.Key, "["+strconv.Itoa(fs.size)+" bytes]"+TruncatedMarker)
+		size = l.encodedSize(ce.Entry, fields)
+	}
+
+	if size > limit {
+		keep := len(ce.Entry.Message) - (size - limit) - len(TruncatedMarker)
+		if keep < 0 {
+			keep = 0
+		}
+		ce.Entry.Message = truncate(ce.Entry.Message, keep)
+	}
+	return fields
+}
+
+// truncate shortens s to at most limit bytes, without splitting a multi-byte rune, and appends TruncatedMarker
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit] + TruncatedMarker
+}
@@ -0,0 +1,82 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/packaged/environment/environment"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_LimitsMessageAndStrings(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	l.SetLimits(Limits{MaxMessageLength: 10, MaxStringLength: 5})
+
+	fields := []zap.Field{zap.String("long", "abcdefghij"), zap.String("short", "abc")}
+	l.Info("a message that is far too long", fields...)
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "a message "+TruncatedMarker, logs[0].Message)
+
+	ctx := logs[0].ContextMap()
+	assert.Equal(t, "abcde"+TruncatedMarker, ctx["long"])
+	assert.Equal(t, "abc", ctx["short"])
+	assert.Equal(t, true, ctx[TruncatedKey])
+	assert.Equal(t, "abcdefghij", fields[0].String, "caller fields should not be modified")
+
+	l.Info("short", zap.String("short", "abc"))
+	logs = observedLogs.TakeAll()
+	assert.Equal(t, "short", logs[0].Message)
+	assert.NotContains(t, logs[0].ContextMap(), TruncatedKey)
+}
+
+func TestLogger_LimitsFieldCount(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	l.SetLimits(Limits{MaxFields: 3})
+	l.AddCommon(zap.Int("common", 1))
+
+	l.Info("test", zap.Int("a", 1), zap.Int("b", 2), zap.Int("c", 3))
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs[0].Context, 3)
+	assert.Equal(t, "common", logs[0].Context[0].Key)
+	assert.Equal(t, "a", logs[0].Context[1].Key)
+	assert.Equal(t, TruncatedKey, logs[0].Context[2].Key)
+}
+
+func TestLogger_LimitsEntrySize(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l, err := InstanceWithConfig(environment.Production, zap.NewProductionConfig(), WithGoogleEncoding)
+	assert.NoError(t, err)
+	l.zapper = zap.New(observedZapCore)
+	l.SetLimits(Limits{MaxEntrySize: 1024})
+
+	large := make([]string, 500)
+	for i := range large {
+		large[i] = "item"
+	}
+	l.Info("big", zap.String("id", "abc"), zap.Any("items", large))
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 1)
+
+	ctx := logs[0].ContextMap()
+	assert.Equal(t, "abc", ctx["id"])
+	assert.True(t, strings.HasSuffix(ctx["items"].(string), "bytes]"+TruncatedMarker))
+	assert.Equal(t, true, ctx[TruncatedKey])
+	assert.LessOrEqual(t, l.encodedSize(logs[0].Entry, logs[0].Context), 1024)
+
+	l.SetLimits(Limits{MaxEntrySize: 200})
+	l.Info(strings.Repeat("x", 1000))
+	logs = observedLogs.TakeAll()
+	assert.True(t, strings.HasSuffix(logs[0].Message, TruncatedMarker))
+	assert.LessOrEqual(t, l.encodedSize(logs[0].Entry, logs[0].Context), 200)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 5))
+	assert.Equal(t, "ab"+TruncatedMarker, truncate("abc", 2))
+	assert.Equal(t, "a"+TruncatedMarker, truncate("aé", 2), "multi-byte runes should not be split")
+}
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"github.com/packaged/environment/environment"
+	"github.com/packaged/logger/v3/ld"
+	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 	"log"
 
@@ -16,6 +18,18 @@ type Logger struct {
 	options []Option
 	env     environment.Environment
 	common  []zap.Field
+
+	redactor *Redactor
+	scrubber *Scrubber
+	limits   Limits
+	sizer    zapcore.Encoder
+	console  bool
+
+	duplicates DuplicatePolicy
+	hooks      []Hook
+	metrics    *Metrics
+	stackTrim  []string
+	schema     *ld.Schema
 }
 
 // I global logger instance
@@ -55,7 +69,7 @@ func InstanceWithConfig(env environment.Environment, cfg zap.Config, options ...
 		log.Println("Unable to create logger", err)
 		return nil, err
 	}
-	return &Logger{env: env, zapper: zapper.WithOptions(zap.AddCallerSkip(1)), options: options}, nil
+	return &Logger{env: env, zapper: zapper.WithOptions(zap.AddCallerSkip(2)), options: options, sizer: newSizer(cfg), console: isConsole(cfg)}, nil
 }
 
 // Clone clones the logger instance
@@ -66,57 +80,158 @@ func (l *Logger) Clone() *Logger {
 
 // AddCommon adds common fields to the logger
 func (l *Logger) AddCommon(fields ...zap.Field) {
-	l.common = append(l.common, fields...)
+	// clip so clones sharing the backing array are not affected
+	l.common = append(l.common[:len(l.common):len(l.common)], fields...)
+}
+
+// WithCommon returns fields with common fields appended, and the keys of any call-site fields colliding with them
+func (l *Logger) withCommon(fields ...zap.Field) ([]zap.Field, []string) {
+	if len(l.common) > 0 && len(fields) > 0 {
+		return l.resolveDuplicates(fields)
+	}
+	all := make([]zap.Field, 0, len(l.common)+len(fields))
+	all = append(all, l.common...)
+	return append(all, fields...), nil
+}
+
+// log checks the level and writes the entry.
+// It must be called directly from the exported logging method so the caller skip stays correct.
+func (l *Logger) log(lvl zapcore.Level, msg string, fields []zap.Field) {
+	if ce := l.zapper.Check(lvl, msg); ce != nil {
+		l.write(ce, fields)
+	}
+}
+
+// logWith is log with the entry annotated before it is written, for entries that describe a location
+// other than the call site.
+func (l *Logger) logWith(lvl zapcore.Level, msg string, fields []zap.Field, annotate func(ent *zapcore.Entry)) {
+	if ce := l.zapper.Check(lvl, msg); ce != nil {
+		annotate(&ce.Entry)
+		l.write(ce, fields)
+	}
+}
+
+// write is the single path every entry takes on its way to the encoder.
+// Problems found with the fields are reported, in development and test environments, once the entry is written.
+func (l *Logger) write(ce *zapcore.CheckedEntry, fields []zap.Field) {
+	// error provided fields take part in duplicate resolution with the common fields
+	fields, collisions := l.withCommon(expandErrors(fields)...)
+	if !l.env.IsDevOrTest() {
+		l.emit(ce, fields)
+		return
+	}
+
+	var violations []string
+	if l.schema != nil {
+		violations = l.schemaViolations(fields)
+	}
+	ent := ce.Entry
+	l.emit(ce, fields)
+	if len(collisions) > 0 {
+		l.report(ent, zapcore.WarnLevel, "duplicate log field keys",
+			zap.Strings("keys", collisions), zap.Stringer("policy", l.duplicates))
+	}
+	if len(violations) > 0 {
+		l.report(ent, zapcore.DPanicLevel, "log field schema violation",
+			zap.String("entry", ent.Message), zap.Strings("violations", violations))
+	}
+}
+
+// report writes an entry describing a problem with another entry, at its call site
+func (l *Logger) report(ent zapcore.Entry, lvl zapcore.Level, msg string, fields ...zap.Field) {
+	if ce := l.zapper.Check(lvl, msg); ce != nil {
+		ce.Entry.Caller = ent.Caller
+		l.emit(ce, fields)
+	}
+}
+
+// emit runs the entry through the hooks, redaction, scrubbing and limits, then writes it
+func (l *Logger) emit(ce *zapcore.CheckedEntry, fields []zap.Field) {
+	if len(l.hooks) > 0 {
+		var keep bool
+		if fields, keep = l.runHooks(ce, fields); !keep {
+			return
+		}
+	}
+	if ce.Entry.Level >= zapcore.ErrorLevel {
+		if stack := l.errorStack(fields); stack != "" {
+			// the origin of the error is more useful than the logging site
+			ce.Entry.Stack = stack
+		}
+	}
+	if l.redactor != nil {
+		fields = l.redactor.Redact(fields)
+	}
+	if l.scrubber != nil {
+		ce.Entry.Message = l.scrubber.Scrub(ce.Entry.Message)
+		fields = l.scrubber.ScrubFields(fields)
+	}
+	if l.console {
+		fields = humanize(fields)
+	}
+	if l.limits != (Limits{}) {
+		fields = l.applyLimits(ce, fields)
+	}
+	if l.metrics != nil {
+		l.metrics.Record(ce.Entry.Level, ce.Entry.LoggerName, l.encodedSize(ce.Entry, fields))
+	}
+	ce.Write(fields...)
+}
+
+// Log logs a message at the provided level. The message includes any fields passed
+// at the log site, as well as any fields accumulated on the logger.
+func (l *Logger) Log(lvl zapcore.Level, msg string, fields ...zap.Field) {
+	l.log(lvl, msg, fields)
 }
 
-// WithCommon returns fields with common fields appended
-func (l *Logger) withCommon(fields ...zap.Field) []zap.Field {
-	return append(l.common, fields...)
+// Enabled reports whether entries at the provided level would be written
+func (l *Logger) Enabled(lvl zapcore.Level) bool {
+	return l.zapper.Core().Enabled(lvl)
 }
 
 // Debug logs a message at DebugLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Debug(msg string, fields ...zap.Field) {
-	l.zapper.Debug(msg, l.withCommon(fields...)...)
+	l.log(zapcore.DebugLevel, msg, fields)
 }
 
 // DebugIf logs a message at DebugLevel if the error is not nil
 func (l *Logger) DebugIf(err error, msg string, fields ...zap.Field) {
 	if err != nil {
-		l.zapper.Debug(msg, l.withCommon(append(fields, zap.Error(err))...)...)
+		l.log(zapcore.DebugLevel, msg, append(fields, zap.Error(err)))
 	}
 }
 
 // Info logs a message at InfoLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Info(msg string, fields ...zap.Field) {
-	l.zapper.Info(msg, l.withCommon(fields...)...)
+	l.log(zapcore.InfoLevel, msg, fields)
 }
 
 // InfoIf logs a message at InfoLevel if the error is not nil
 func (l *Logger) InfoIf(err error, msg string, fields ...zap.Field) {
 	if err != nil {
-		l.zapper.Info(msg, l.withCommon(append(fields, zap.Error(err))...)...)
+		l.log(zapcore.InfoLevel, msg, append(fields, zap.Error(err)))
 	}
 }
 
 // Warn logs a message at WarnLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Warn(msg string, fields ...zap.Field) {
-	l.zapper.Warn(msg, l.withCommon(fields...)...)
+	l.log(zapcore.WarnLevel, msg, fields)
 }
 
 // WarnIf logs a message at WarnLevel if the error is not nil
 func (l *Logger) WarnIf(err error, msg string, fields ...zap.Field) {
 	if err != nil {
-		l.zapper.Warn(msg, l.withCommon(append(fields, zap.Error(err))...)...)
+		l.log(zapcore.WarnLevel, msg, append(fields, zap.Error(err)))
 	}
 }
 
 // Error logs a message at ErrorLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Error(msg string, fields ...zap.Field) {
-	l.zapper.Error(msg, l.withCommon(fields...)...)
+	l.log(zapcore.ErrorLevel, msg, fields)
 }
 
 // DPanic logs a message at DPanicLevel. The message includes any fields
@@ -126,7 +241,7 @@ func (l *Logger) Error(msg string, fields ...zap.Field) {
 // "development panic"). This is useful for catching errors that are
 // recoverable, but shouldn't ever happen.
 func (l *Logger) DPanic(msg string, fields ...zap.Field) {
-	l.zapper.DPanic(msg, l.withCommon(fields...)...)
+	l.log(zapcore.DPanicLevel, msg, fields)
 }
 
 // Panic logs a message at PanicLevel. The message includes any fields passed
@@ -134,13 +249,13 @@ func (l *Logger) DPanic(msg string, fields ...zap.Field) {
 //
 // The logger then panics, even if logging at PanicLevel is disabled.
 func (l *Logger) Panic(msg string, fields ...zap.Field) {
-	l.zapper.Panic(msg, l.withCommon(fields...)...)
+	l.log(zapcore.PanicLevel, msg, fields)
 }
 
 // ErrorIf logs a message at ErrorLevel if the error is not nil
 func (l *Logger) ErrorIf(err error, msg string, fields ...zap.Field) {
 	if err != nil {
-		l.zapper.Error(msg, l.withCommon(append(fields, zap.Error(err))...)...)
+		l.log(zapcore.ErrorLevel, msg, append(fields, zap.Error(err)))
 	}
 }
 
@@ -150,13 +265,13 @@ func (l *Logger) ErrorIf(err error, msg string, fields ...zap.Field) {
 // The logger then calls os.Exit(1), even if logging at FatalLevel is
 // disabled.
 func (l *Logger) Fatal(msg string, fields ...zap.Field) {
-	l.zapper.Fatal(msg, l.withCommon(fields...)...)
+	l.log(zapcore.FatalLevel, msg, fields)
 }
 
 // FatalIf logs a fatal message if the error is not nil
 func (l *Logger) FatalIf(err error, msg string, fields ...zap.Field) {
 	if err != nil {
-		l.zapper.Fatal(msg, l.withCommon(append(fields, zap.Error(err))...)...)
+		l.log(zapcore.FatalLevel, msg, append(fields, zap.Error(err)))
 	}
 }
 
@@ -169,7 +284,8 @@ func (l *Logger) Sync() {
 // Replace replaces the global logger instance
 func Replace(logger *Logger) { inst = logger }
 
-// ObserverForTest returns an observer for the global logger instance if it exists
+// ObserverForTest returns an observer for the global logger instance if it exists.
+// The replaced logger is not restored, see ObserveGlobal and NewTestLogger.
 func ObserverForTest() *observer.ObservedLogs {
 	if inst == nil || !inst.env.IsDevOrTest() {
 		return nil
@@ -8,6 +8,7 @@ import (
 	"go.uber.org/zap/zaptest/observer"
 	"reflect"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -263,3 +264,34 @@ func TestSetupOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestCaller(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l, err := InstanceWithConfig(environment.UnitTest, zap.NewDevelopmentConfig())
+	assert.NoError(t, err)
+	l.zapper = l.zapper.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return observedZapCore }))
+
+	l.Info("info")
+	l.ErrorIf(errors.New("test"), "error")
+	l.TimedLog((&TimedLogConfig{DebugDuration: time.Nanosecond}).NewLog("timed"))
+
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 3)
+	for _, entry := range logs {
+		assert.Contains(t, entry.Caller.File, "logger_test.go", entry.Message)
+	}
+}
+
+func TestLogger_Log(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.InfoLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	assert.False(t, l.Enabled(zapcore.DebugLevel))
+	assert.True(t, l.Enabled(zapcore.WarnLevel))
+
+	l.Log(zapcore.DebugLevel, "debug")
+	l.Log(zapcore.WarnLevel, "warn", zap.Int("n", 1))
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 1)
+	assert.Equal(t, zapcore.WarnLevel, logs[0].Level)
+	assert.Equal(t, "warn", logs[0].Message)
+}
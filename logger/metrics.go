@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Metrics counts log entries and encoded bytes by level and logger name.
+// A single Metrics may be shared by many loggers.
+type Metrics struct {
+	mu       sync.RWMutex
+	counters map[metricKey]*metricCounter
+}
+
+type metricKey struct {
+	level zapcore.Level
+	name  string
+}
+
+type metricCounter struct {
+	entries atomic.Int64
+	bytes   atomic.Int64
+}
+
+// MetricSample is a snapshot of the counters for a single level and logger name
+type MetricSample struct {
+	Level   zapcore.Level
+	Name    string
+	Entries int64
+	Bytes   int64
+}
+
+// NewMetrics creates an empty set of log volume counters
+func NewMetrics() *Metrics {
+	return &Metrics{counters: make(map[metricKey]*metricCounter)}
+}
+
+// SetMetrics sets the counters updated for every entry written, nil disables counting.
+// Counting bytes requires each entry to be encoded a second time.
+func (l *Logger) SetMetrics(m *Metrics) {
+	l.metrics = m
+}
+
+// Named returns a clone of the logger with the name appended, see zap.Logger.Named
+func (l *Logger) Named(name string) *Logger {
+	nl := l.Clone()
+	nl.zapper = nl.zapper.Named(name)
+	return nl
+}
+
+// Record adds an entry of the provided size to the counters
+func (m *Metrics) Record(level zapcore.Level, name string, size int) {
+	key := metricKey{level: level, name: name}
+	m.mu.RLock()
+	c, ok := m.counters[key]
+	m.mu.RUnlock()
+	if !ok {
+		m.mu.Lock()
+		if c, ok = m.counters[key]; !ok {
+			c = &metricCounter{}
+			m.counters[key] = c
+		}
+		m.mu.Unlock()
+	}
+	c.entries.Add(1)
+	c.bytes.Add(int64(size))
+}
+
+// Snapshot returns the current counters, ordered by logger name then level
+func (m *Metrics) Snapshot() []MetricSample {
+	m.mu.RLock()
+	samples := make([]MetricSample, 0, len(m.counters))
+	for k, c := range m.counters {
+		samples = append(samples, MetricSample{Level: k.level, Name: k.name, Entries: c.entries.Load(), Bytes: c.bytes.Load()})
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Name != samples[j].Name {
+			return samples[i].Name < samples[j].Name
+		}
+		return samples[i].Level < samples[j].Level
+	})
+	return samples
+}
+
+// ServeHTTP writes the counters in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(m.prometheus())
+}
+
+func (m *Metrics) prometheus() []byte {
+	samples := m.Snapshot()
+	buf := &bytes.Buffer{}
+	writeMetric := func(name, help string, value func(MetricSample) int64) {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, s := range samples {
+			fmt.Fprintf(buf, "%s{level=\"%s\",logger=\"%s\"} %d\n", name, s.Level.String(), escapeLabel(s.Name), value(s))
+		}
+	}
+	writeMetric("log_entries_total", "Number of log entries written.", func(s MetricSample) int64 { return s.Entries })
+	writeMetric("log_bytes_total", "Number of encoded log bytes written.", func(s MetricSample) int64 { return s.Bytes })
+	return buf.Bytes()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+// Publish exposes the counters through expvar under the provided name.
+// As with expvar.Publish, publishing the same name twice panics.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, m)
+}
+
+func (m *Metrics) expvar() map[string]map[string]map[string]int64 {
+	result := map[string]map[string]map[string]int64{"entries": {}, "bytes": {}}
+	for _, s := range m.Snapshot() {
+		lvl := s.Level.String()
+		if result["entries"][lvl] == nil {
+			result["entries"][lvl] = map[string]int64{}
+			result["bytes"][lvl] = map[string]int64{}
+		}
+		result["entries"][lvl][s.Name] = s.Entries
+		result["bytes"][lvl][s.Name] = s.Bytes
+	}
+	return result
+}
+
+// String implements expvar.Var
+func (m *Metrics) String() string {
+	b, _ := json.Marshal(m.expvar())
+	return string(b)
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_Metrics(t *testing.T) {
+	observedZapCore, _ := observer.New(zap.InfoLevel)
+	m := NewMetrics()
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	l.SetMetrics(m)
+
+	l.Debug("not enabled")
+	l.Info("one")
+	l.Info("two", zap.String("k", "v"))
+	l.Named("db").Warn("three")
+
+	samples := m.Snapshot()
+	assert.Len(t, samples, 2)
+	assert.Equal(t, MetricSample{Level: zapcore.InfoLevel, Name: "", Entries: 2, Bytes: samples[0].Bytes}, samples[0])
+	assert.Greater(t, samples[0].Bytes, int64(0))
+	assert.Equal(t, "db", samples[1].Name)
+	assert.Equal(t, zapcore.WarnLevel, samples[1].Level)
+	assert.Equal(t, int64(1), samples[1].Entries)
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	m := NewMetrics()
+	m.Record(zapcore.ErrorLevel, `api"v1`, 100)
+	m.Record(zapcore.ErrorLevel, `api"v1`, 50)
+	m.Record(zapcore.InfoLevel, "", 10)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	assert.Equal(t, `# HELP log_entries_total Number of log entries written.
+# TYPE log_entries_total counter
+log_entries_total{level="info",logger=""} 1
+log_entries_total{level="error",logger="api\"v1"} 2
+# HELP log_bytes_total Number of encoded log bytes written.
+# TYPE log_bytes_total counter
+log_bytes_total{level="info",logger=""} 10
+log_bytes_total{level="error",logger="api\"v1"} 150
+`, rec.Body.String())
+}
+
+var publishedMetrics = NewMetrics()
+
+func TestMetrics_Publish(t *testing.T) {
+	m := publishedMetrics
+	m.Record(zapcore.InfoLevel, "svc", 10)
+	if expvar.Get("logger_metrics_test") == nil {
+		m.Publish("logger_metrics_test")
+	}
+
+	var got map[string]map[string]map[string]int64
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get("logger_metrics_test").String()), &got))
+	assert.Greater(t, got["entries"]["info"]["svc"], int64(0))
+	assert.Equal(t, got["entries"]["info"]["svc"]*10, got["bytes"]["info"]["svc"])
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// PrefixSeparator separates the namespaces within keys produced by ld.Prefix
+const PrefixSeparator = ":"
+
+// nestedEncodingSuffix is appended to the encoding name to select the nesting variant of an encoder
+const nestedEncodingSuffix = "+nested"
+
+func init() {
+	_ = zap.RegisterEncoder("json"+nestedEncodingSuffix, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return &nestedEncoder{Encoder: zapcore.NewJSONEncoder(cfg)}, nil
+	})
+	_ = zap.RegisterEncoder("console"+nestedEncodingSuffix, func(cfg zapcore.EncoderConfig) (zapcore.Encoder, error) {
+		return &nestedEncoder{Encoder: zapcore.NewConsoleEncoder(cfg)}, nil
+	})
+}
+
+// NestedPrefixes encodes fields with colon separated keys, such as those produced by ld.Prefix, as nested
+// objects, so "req:id" is written as {"req":{"id":...}}.
+// It must be applied after any option that sets the encoding, such as WithConsoleEncoding.
+func NestedPrefixes(config *zap.Config) {
+	if !strings.HasSuffix(config.Encoding, nestedEncodingSuffix) {
+		config.Encoding += nestedEncodingSuffix
+	}
+}
+
+// nestedEncoder groups prefixed fields into nested objects before delegating to the wrapped encoder
+type nestedEncoder struct {
+	zapcore.Encoder
+}
+
+// Clone implements zapcore.Encoder
+func (e *nestedEncoder) Clone() zapcore.Encoder {
+	return &nestedEncoder{Encoder: e.Encoder.Clone()}
+}
+
+// EncodeEntry implements zapcore.Encoder
+func (e *nestedEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	return e.Encoder.EncodeEntry(ent, nestFields(fields))
+}
+
+// nestFields converts prefixed keys into nested objects.  A prefix that is also the key of another field
+// is not nested, so no key is written twice.
+func nestFields(fields []zap.Field) []zap.Field {
+	keys := make(map[string]bool, len(fields))
+	prefixed := false
+	for _, f := range fields {
+		keys[f.Key] = true
+		prefixed = prefixed || strings.Contains(f.Key, PrefixSeparator)
+	}
+	if !prefixed {
+		return fields
+	}
+
+	root := &fieldNode{}
+	for _, f := range fields {
+		path := strings.Split(f.Key, PrefixSeparator)
+		depth := 0
+		for depth < len(path)-1 && path[depth] != "" && !keys[strings.Join(path[:depth+1], PrefixSeparator)] {
+			depth++
+		}
+		node := root
+		for _, name := range path[:depth] {
+			node = node.group(name)
+		}
+		f.Key = strings.Join(path[depth:], PrefixSeparator)
+		node.children = append(node.children, &fieldNode{field: f})
+	}
+
+	nested := make([]zap.Field, len(root.children))
+	for i, child := range root.children {
+		nested[i] = child.asField()
+	}
+	return nested
+}
+
+// fieldNode is either a single field, or a named group of nodes
+type fieldNode struct {
+	field    zap.Field
+	name     string
+	children []*fieldNode
+	isGroup  bool
+}
+
+func (n *fieldNode) group(name string) *fieldNode {
+	for _, child := range n.children {
+		if child.isGroup && child.name == name {
+			return child
+		}
+	}
+	child := &fieldNode{name: name, isGroup: true}
+	n.children = append(n.children, child)
+	return child
+}
+
+func (n *fieldNode) asField() zap.Field {
+	if n.isGroup {
+		return zap.Object(n.name, n)
+	}
+	return n.field
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler
+func (n *fieldNode) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, child := range n.children {
+		child.asField().AddTo(enc)
+	}
+	return nil
+}
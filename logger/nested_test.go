@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/packaged/environment/environment"
+	"github.com/packaged/logger/v3/ld"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNestedPrefixes(t *testing.T) {
+	cfg := &zap.Config{Encoding: "json"}
+	NestedPrefixes(cfg)
+	NestedPrefixes(cfg)
+	assert.Equal(t, "json+nested", cfg.Encoding, "applying the option twice should not change the encoding again")
+}
+
+func TestNestFields(t *testing.T) {
+	enc := &nestedEncoder{Encoder: zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})}
+	buf, err := enc.EncodeEntry(zapcore.Entry{Message: "nested"}, []zap.Field{
+		zap.String("plain", "value"),
+		ld.Prefix("req", ld.Method("GET")),
+		ld.Prefix("a", ld.Prefix("b", zap.Int("c", 1))),
+		ld.Prefix("req", ld.URL("/path")),
+		ld.Group("client", ld.IP("127.0.0.1")),
+		ld.Prefix("client", ld.UserAgent("test")),
+		ld.Prefix("plain", zap.String("type", "string")),
+	})
+	assert.NoError(t, err)
+
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, map[string]any{
+		"msg":               "nested",
+		"plain":             "value",
+		"plain:type":        "string",
+		"req":               map[string]any{"method": "GET", "url": "/path"},
+		"a":                 map[string]any{"b": map[string]any{"c": float64(1)}},
+		"client":            map[string]any{"ip": "127.0.0.1"},
+		"client:user-agent": "test",
+	}, got, "prefixes colliding with another key should remain flat")
+}
+
+func TestNestFields_Unprefixed(t *testing.T) {
+	fields := []zap.Field{zap.String("a", "b")}
+	assert.Equal(t, fields, nestFields(fields))
+}
+
+func TestNestedPrefixes_Logger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.json")
+	cfg := zap.NewProductionConfig()
+	cfg.OutputPaths = []string{path}
+	l, err := InstanceWithConfig(environment.UnitTest, cfg, WithGoogleEncoding, NestedPrefixes)
+	assert.NoError(t, err)
+
+	l.Info("nested", ld.Prefix("req", ld.Method("GET")), ld.Prefix("req", ld.Port(80)))
+	l.Sync()
+
+	raw, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, map[string]any{"method": "GET", "port": float64(80)}, got["req"])
+}
@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// RecoverConfig configures how recovered panics are logged
+type RecoverConfig struct {
+	// DPanic logs at DPanicLevel rather than ErrorLevel, which panics again in development
+	DPanic bool
+	// RePanic panics with the original value once it has been logged
+	RePanic bool
+}
+
+var defaultRecoverConfig = &RecoverConfig{}
+
+// DefaultRecoverConfig logs recovered panics at ErrorLevel without panicking again
+func DefaultRecoverConfig() *RecoverConfig {
+	return defaultRecoverConfig
+}
+
+// Recover logs a recovered panic through FromContext(ctx), with the panic value and the stack of the
+// panicking goroutine.  It must be deferred directly:
+//
+//	defer logger.Recover(ctx, "processing failed")
+func Recover(ctx context.Context, msg string, fields ...zap.Field) {
+	if r := recover(); r != nil {
+		defaultRecoverConfig.handle(ctx, r, msg, fields)
+	}
+}
+
+// Go runs fn in a new goroutine, logging any panic through FromContext(ctx)
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	defaultRecoverConfig.Go(ctx, fn)
+}
+
+// Recover logs a recovered panic according to the config, it must be deferred directly
+func (c *RecoverConfig) Recover(ctx context.Context, msg string, fields ...zap.Field) {
+	if r := recover(); r != nil {
+		c.handle(ctx, r, msg, fields)
+	}
+}
+
+// Go runs fn in a new goroutine, logging any panic according to the config
+func (c *RecoverConfig) Go(ctx context.Context, fn func(ctx context.Context)) {
+	go func() {
+		defer c.Recover(ctx, "recovered panic in goroutine")
+		fn(ctx)
+	}()
+}
+
+func (c *RecoverConfig) handle(ctx context.Context, r any, msg string, fields []zap.Field) {
+	if l := FromContext(ctx); l != nil {
+		lvl := zapcore.ErrorLevel
+		if c.DPanic {
+			lvl = zapcore.DPanicLevel
+		}
+
+		caller, stack := panicStack()
+		l.logWith(lvl, msg, append(fields, zap.Any("panic", r)), func(ent *zapcore.Entry) {
+			if ent.Caller.Defined && caller.Defined {
+				ent.Caller = caller
+			}
+			ent.Stack = stack
+		})
+	}
+
+	if c.RePanic {
+		panic(r)
+	}
+}
+
+// panicStack returns the frame that panicked, and the stack from that frame down
+func panicStack() (zapcore.EntryCaller, string) {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	frames := runtime.CallersFrames(pcs)
+
+	var caller zapcore.EntryCaller
+	stack := &strings.Builder{}
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			panicking = true
+		case !panicking || (!caller.Defined && strings.HasPrefix(frame.Function, "runtime.")):
+			// frames within the recovery, or the runtime raising the panic
+		default:
+			if !caller.Defined {
+				caller = zapcore.EntryCaller{Defined: true, PC: frame.PC, File: frame.File, Line: frame.Line, Function: frame.Function}
+			}
+			writeFrame(stack, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return caller, stack.String()
+}
+
+// writeFrame writes the frame in the same format as zap stack traces
+func writeFrame(b *strings.Builder, frame runtime.Frame) {
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteString(frame.Function)
+	b.WriteString("\n\t")
+	b.WriteString(frame.File)
+	b.WriteByte(':')
+	b.WriteString(strconv.Itoa(frame.Line))
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func panicky() {
+	var m map[string]int
+	m["boom"] = 1
+}
+
+func TestRecover(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore, zap.AddCaller())}
+	ctx := NewContext(context.Background(), l)
+
+	func() {
+		defer Recover(ctx, "recovered", zap.String("job", "abc"))
+		panicky()
+	}()
+
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 1)
+	assert.Equal(t, "recovered", logs[0].Message)
+	assert.Equal(t, zapcore.ErrorLevel, logs[0].Level)
+	assert.Equal(t, "abc", logs[0].ContextMap()["job"])
+	assert.Contains(t, logs[0].ContextMap()["panic"], "assignment to entry in nil map")
+	assert.Equal(t, "github.com/packaged/logger/v3/logger.panicky", logs[0].Caller.Function)
+	assert.Contains(t, logs[0].Stack, "logger.panicky")
+	assert.Contains(t, logs[0].Stack, "logger.TestRecover")
+	assert.NotContains(t, logs[0].Stack, "runtime.gopanic")
+	assert.NotContains(t, logs[0].Stack, "logger.Recover")
+
+	// no panic, no log
+	func() {
+		defer Recover(ctx, "recovered")
+	}()
+	assert.Equal(t, 0, observedLogs.Len())
+}
+
+func TestRecoverConfig_RePanic(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	ctx := NewContext(context.Background(), &Logger{zapper: zap.New(observedZapCore)})
+	cfg := &RecoverConfig{DPanic: true, RePanic: true}
+
+	err := errors.New("failed")
+	assert.PanicsWithValue(t, err, func() {
+		defer cfg.Recover(ctx, "recovered")
+		panic(err)
+	})
+
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 1)
+	assert.Equal(t, zapcore.DPanicLevel, logs[0].Level)
+	assert.Equal(t, "failed", logs[0].ContextMap()["panic"])
+}
+
+func TestGo(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	ctx := NewContext(context.Background(), &Logger{zapper: zap.New(observedZapCore)})
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	Go(ctx, func(ctx context.Context) {
+		defer wg.Done()
+		panic("goroutine failure")
+	})
+	wg.Wait()
+
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 1 }, time.Second, time.Millisecond)
+	logs := observedLogs.TakeAll()
+	assert.Equal(t, "recovered panic in goroutine", logs[0].Message)
+	assert.Equal(t, "goroutine failure", logs[0].ContextMap()["panic"])
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/packaged/logger/v3/ld"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redactor masks or hashes sensitive field values before they reach the encoder.
+//
+// Keys are case-insensitive glob patterns (see path.Match), matched against the full field key and the
+// final segment of an ld.Prefix key.  Values of ld.Secret fields are always redacted.
+type Redactor struct {
+	Keys []string
+	// Hash replaces values with a truncated sha256 of the value, so equal values can still be correlated
+	Hash bool
+	// Mask is written in place of redacted values when Hash is false
+	Mask string
+}
+
+// NewRedactor creates a redactor for the provided key patterns, or ld.SensitiveKeys if none are provided
+func NewRedactor(keys ...string) *Redactor {
+	if len(keys) == 0 {
+		keys = ld.SensitiveKeys
+	}
+	r := &Redactor{Mask: ld.RedactedMask}
+	for _, k := range keys {
+		r.Keys = append(r.Keys, strings.ToLower(k))
+	}
+	return r
+}
+
+// SetRedactor sets the redactor applied to common and call-site fields, nil disables redaction
+func (l *Logger) SetRedactor(r *Redactor) {
+	l.redactor = r
+}
+
+// Redact returns fields with any sensitive values replaced.  The provided slice is not modified.
+func (r *Redactor) Redact(fields []zap.Field) []zap.Field {
+	var out []zap.Field
+	for i, f := range fields {
+		nf, changed := r.redactField(f)
+		if !changed {
+			if out != nil {
+				out = append(out, f)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]zap.Field, i, len(fields))
+			copy(out, fields[:i])
+		}
+		out = append(out, nf...)
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (r *Redactor) redactField(f zap.Field) ([]zap.Field, bool) {
+	if f.Type == zapcore.SkipType {
+		return nil, false
+	}
+
+	if s, ok := f.Interface.(ld.SecretValue); ok {
+		return []zap.Field{zap.String(f.Key, r.conceal(s.Value()))}, true
+	}
+
+	if r.Matches(f.Key) {
+		return []zap.Field{zap.String(f.Key, r.conceal(fieldValue(f)))}, true
+	}
+
+	switch f.Type {
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.InlineMarshalerType, zapcore.ReflectType:
+		return r.redactNested(f)
+	}
+	return nil, false
+}
+
+// redactNested flattens complex values into generic maps and slices so nested keys can be inspected.
+// The original field is retained when nothing within it needs redacting.
+func (r *Redactor) redactNested(f zap.Field) ([]zap.Field, bool) {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+
+	changed := false
+	for k, v := range enc.Fields {
+		if k != f.Key && r.Matches(k) {
+			// inline marshalers add their keys directly alongside the field
+			enc.Fields[k] = r.conceal(v)
+			changed = true
+			continue
+		}
+		generic, err := toGeneric(v)
+		if err != nil {
+			continue
+		}
+		if redacted, ok := r.walk(generic); ok {
+			enc.Fields[k] = redacted
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, false
+	}
+
+	if f.Type != zapcore.InlineMarshalerType {
+		return []zap.Field{zap.Any(f.Key, enc.Fields[f.Key])}, true
+	}
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]zap.Field, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, zap.Any(k, enc.Fields[k]))
+	}
+	return result, true
+}
+
+func (r *Redactor) walk(v any) (any, bool) {
+	changed := false
+	switch val := v.(type) {
+	case map[string]any:
+		for k, nested := range val {
+			if r.Matches(k) {
+				val[k] = r.conceal(nested)
+				changed = true
+			} else if nv, ok := r.walk(nested); ok {
+				val[k] = nv
+				changed = true
+			}
+		}
+	case []any:
+		for i, nested := range val {
+			if nv, ok := r.walk(nested); ok {
+				val[i] = nv
+				changed = true
+			}
+		}
+	}
+	return v, changed
+}
+
+// Matches reports whether the key should be redacted
+func (r *Redactor) Matches(key string) bool {
+	if key == "" {
+		return false
+	}
+	key = strings.ToLower(key)
+	last := key
+	if idx := strings.LastIndex(key, ":"); idx >= 0 {
+		last = key[idx+1:]
+	}
+	for _, pattern := range r.Keys {
+		pattern = strings.ToLower(pattern)
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		if last != key {
+			if ok, _ := path.Match(pattern, last); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *Redactor) conceal(v any) string {
+	if !r.Hash {
+		if r.Mask == "" {
+			return ld.RedactedMask
+		}
+		return r.Mask
+	}
+	if s, ok := v.(ld.SecretValue); ok {
+		v = s.Value()
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprint(v)))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// fieldValue returns the raw value of a field for hashing
+func fieldValue(f zap.Field) any {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	return enc.Fields[f.Key]
+}
+
+// toGeneric converts a value to the maps, slices and scalars produced by encoding/json
+func toGeneric(v any) (any, error) {
+	if _, ok := v.(string); ok {
+		return v, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic any
+	err = dec.Decode(&generic)
+	return generic, err
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/packaged/logger/v3/ld"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type loginRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Session  struct {
+		AuthToken string `json:"authToken"`
+		Expires   int    `json:"expires"`
+	} `json:"session"`
+}
+
+func TestRedactorMatches(t *testing.T) {
+	r := NewRedactor()
+	tests := map[string]bool{
+		"password":      true,
+		"Password":      true,
+		"access_token":  true,
+		"tokenId":       true,
+		"Authorization": true,
+		"req:password":  true,
+		"user":          false,
+		"":              false,
+	}
+	for key, expect := range tests {
+		assert.Equal(t, expect, r.Matches(key), key)
+	}
+}
+
+func TestLogger_Redaction(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	l.SetRedactor(NewRedactor("password", "*token*", "authorization"))
+	l.AddCommon(zap.String("authorization", "Bearer abc"))
+
+	req := loginRequest{User: "bob", Password: "hunter2"}
+	req.Session.AuthToken = "xyz"
+	req.Session.Expires = 30
+
+	l.Info("login", zap.String("user", "bob"), ld.Secret("pin", 1234), zap.Any("request", req))
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 1)
+
+	ctx := logs[0].ContextMap()
+	assert.Equal(t, ld.RedactedMask, ctx["authorization"])
+	assert.Equal(t, "bob", ctx["user"])
+	assert.Equal(t, ld.RedactedMask, ctx["pin"])
+
+	nested := ctx["request"].(map[string]interface{})
+	assert.Equal(t, "bob", nested["user"])
+	assert.Equal(t, ld.RedactedMask, nested["password"])
+	session := nested["session"].(map[string]interface{})
+	assert.Equal(t, ld.RedactedMask, session["authToken"])
+	assert.EqualValues(t, "30", session["expires"].(interface{ String() string }).String())
+}
+
+func TestLogger_RedactionHash(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	r := NewRedactor()
+	r.Hash = true
+	l.SetRedactor(r)
+
+	l.Info("a", zap.String("password", "hunter2"), ld.Secret("key", "hunter2"), zap.Int("api-key", 5))
+	ctx := observedLogs.TakeAll()[0].ContextMap()
+	hashed := ctx["password"].(string)
+	assert.True(t, strings.HasPrefix(hashed, "sha256:"))
+	assert.Equal(t, hashed, ctx["key"])
+	assert.NotEqual(t, hashed, ctx["api-key"])
+}
+
+func TestRedactor_Unchanged(t *testing.T) {
+	r := NewRedactor()
+	fields := []zap.Field{
+		zap.String("user", "bob"),
+		zap.Skip(),
+		zap.Any("data", map[string]string{"name": "x"}),
+		zap.Strings("list", []string{"a"}),
+	}
+	out := r.Redact(fields)
+	assert.Equal(t, &fields[0], &out[0], "expected the original slice to be returned")
+}
+
+func TestRedactor_Inline(t *testing.T) {
+	r := &Redactor{Keys: []string{"secret"}}
+	out := r.Redact([]zap.Field{
+		zap.String("before", "1"),
+		zap.Inline(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("secret", "abc")
+			enc.AddString("visible", "def")
+			return nil
+		})),
+	})
+	assert.Len(t, out, 3)
+	assert.Equal(t, "before", out[0].Key)
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range out {
+		f.AddTo(enc)
+	}
+	assert.Equal(t, ld.RedactedMask, enc.Fields["secret"])
+	assert.Equal(t, "def", enc.Fields["visible"])
+}
@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TimedLogRegistry holds named timed log configs, so thresholds can be tuned without a deploy.
+// Configs are looked up by name, usually the log message, falling back to a default config.
+type TimedLogRegistry struct {
+	mu       sync.RWMutex
+	fallback *TimedLogConfig
+	configs  map[string]*TimedLogConfig
+}
+
+// TimedLogSpec is the configuration of a named timed log, durations use time.ParseDuration syntax.
+// Thresholds that are not set are inherited from the registry fallback.
+type TimedLogSpec struct {
+	Error     string `json:"error,omitempty"`
+	Warn      string `json:"warn,omitempty"`
+	Info      string `json:"info,omitempty"`
+	Debug     string `json:"debug,omitempty"`
+	Aggregate string `json:"aggregate,omitempty"`
+}
+
+// NewTimedLogRegistry creates an empty registry, names without a config use fallback, or
+// DefaultTimedLogConfig if nil
+func NewTimedLogRegistry(fallback *TimedLogConfig) *TimedLogRegistry {
+	if fallback == nil {
+		fallback = DefaultTimedLogConfig()
+	}
+	return &TimedLogRegistry{fallback: fallback, configs: make(map[string]*TimedLogConfig)}
+}
+
+// Get returns the config for the name, or the fallback
+func (r *TimedLogRegistry) Get(name string) *TimedLogConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if cfg, ok := r.configs[name]; ok {
+		return cfg
+	}
+	return r.fallback
+}
+
+// Set sets the config for the name
+func (r *TimedLogRegistry) Set(name string, cfg *TimedLogConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configs[name] = cfg
+}
+
+// NewLog starts a timed log using the config named by the message
+func (r *TimedLogRegistry) NewLog(message string, fields ...zap.Field) *TimedLog {
+	return r.Get(message).NewLog(message, fields...)
+}
+
+// NewNamedLog starts a timed log using the config for the name
+func (r *TimedLogRegistry) NewNamedLog(name, message string, fields ...zap.Field) *TimedLog {
+	return r.Get(name).NewLog(message, fields...)
+}
+
+// Load replaces every config with those from the JSON object of TimedLogSpec keyed by name, overridden by
+// the TimedLogPrefix variables within environ, such as os.Environ().  Either may be empty.
+// Loading again reloads the registry; on error the existing configs are kept.
+func (r *TimedLogRegistry) Load(data []byte, environ []string) error {
+	specs := map[string]TimedLogSpec{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &specs); err != nil {
+			return fmt.Errorf("timed log registry: %w", err)
+		}
+	}
+	envSpecs, err := parseTimedLogEnv(environ)
+	if err != nil {
+		return err
+	}
+	for name, spec := range envSpecs {
+		specs[name] = spec
+	}
+
+	configs := make(map[string]*TimedLogConfig, len(specs))
+	for name, spec := range specs {
+		cfg, err := spec.config(r.fallback)
+		if err != nil {
+			return fmt.Errorf("timed log %q: %w", name, err)
+		}
+		configs[name] = cfg
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configs = configs
+	return nil
+}
+
+// LoadFile loads the JSON file at path, overridden by the environment, see Load
+func (r *TimedLogRegistry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("timed log registry: %w", err)
+	}
+	return r.Load(data, os.Environ())
+}
+
+// LoadEnv loads the TimedLogPrefix variables within environ, see Load
+func (r *TimedLogRegistry) LoadEnv(environ []string) error {
+	return r.Load(nil, environ)
+}
+
+// parseTimedLogEnv parses variables such as PACKAGED__TIMED_LOG_db.query=warn:2s,error:10s
+func parseTimedLogEnv(environ []string) (map[string]TimedLogSpec, error) {
+	specs := map[string]TimedLogSpec{}
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, TimedLogPrefix.String()) {
+			continue
+		}
+		name := strings.TrimPrefix(key, TimedLogPrefix.String())
+		var spec TimedLogSpec
+		for _, part := range strings.Split(value, ",") {
+			level, d, ok := strings.Cut(strings.TrimSpace(part), ":")
+			if !ok {
+				return nil, fmt.Errorf("%s: expected level:duration, got %q", key, part)
+			}
+			switch strings.ToLower(level) {
+			case "error":
+				spec.Error = d
+			case "warn":
+				spec.Warn = d
+			case "info":
+				spec.Info = d
+			case "debug":
+				spec.Debug = d
+			case "aggregate":
+				spec.Aggregate = d
+			default:
+				return nil, fmt.Errorf("%s: unknown level %q", key, level)
+			}
+		}
+		specs[name] = spec
+	}
+	return specs, nil
+}
+
+func (s TimedLogSpec) config(fallback *TimedLogConfig) (*TimedLogConfig, error) {
+	// settings the spec cannot express, such as the clock and outcome rule, are inherited from the fallback
+	copied := *fallback
+	cfg := &copied
+	for _, d := range []struct {
+		value  string
+		target *time.Duration
+	}{
+		{s.Error, &cfg.ErrorDuration},
+		{s.Warn, &cfg.WarnDuration},
+		{s.Info, &cfg.InfoDuration},
+		{s.Debug, &cfg.DebugDuration},
+		{s.Aggregate, &cfg.AggregateInterval},
+	} {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, err
+		}
+		*d.target = parsed
+	}
+	return cfg, nil
+}
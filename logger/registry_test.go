@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimedLogRegistry(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	fallback := &TimedLogConfig{ErrorDuration: time.Minute, InfoDuration: time.Second, Watchdog: true, Clock: clock}
+	r := NewTimedLogRegistry(fallback)
+	assert.Equal(t, fallback, r.Get("db.query"))
+	assert.Equal(t, DefaultTimedLogConfig(), NewTimedLogRegistry(nil).Get("db.query"))
+
+	err := r.Load([]byte(`{"db.query":{"warn":"2s","error":"10s"},"cache":{"aggregate":"1m"}}`), []string{
+		"PATH=/bin",
+		"PACKAGED__TIMED_LOG_cache=debug:1ms, aggregate:30s",
+	})
+	assert.NoError(t, err)
+
+	query := r.Get("db.query")
+	assert.Equal(t, 10*time.Second, query.ErrorDuration)
+	assert.Equal(t, 2*time.Second, query.WarnDuration)
+	assert.Equal(t, time.Second, query.InfoDuration, "unset thresholds should be inherited from the fallback")
+	assert.True(t, query.Watchdog)
+	assert.Same(t, clock, query.Clock, "settings without a spec should be inherited from the fallback")
+
+	cache := r.Get("cache")
+	assert.Equal(t, time.Millisecond, cache.DebugDuration)
+	assert.Equal(t, 30*time.Second, cache.AggregateInterval, "the environment should replace the JSON config")
+
+	tl := r.NewLog("db.query")
+	assert.Equal(t, query, tl.config)
+	tl = r.NewNamedLog("cache", "lookup")
+	assert.Equal(t, cache, tl.config)
+	assert.Equal(t, "lookup", tl.message)
+}
+
+func TestTimedLogRegistry_Reload(t *testing.T) {
+	r := NewTimedLogRegistry(nil)
+	assert.NoError(t, r.LoadEnv([]string{"PACKAGED__TIMED_LOG_a=warn:1s"}))
+	assert.Equal(t, time.Second, r.Get("a").WarnDuration)
+
+	assert.Error(t, r.LoadEnv([]string{"PACKAGED__TIMED_LOG_a=warn:soon"}))
+	assert.Error(t, r.LoadEnv([]string{"PACKAGED__TIMED_LOG_a=slow:1s"}))
+	assert.Error(t, r.LoadEnv([]string{"PACKAGED__TIMED_LOG_a=1s"}))
+	assert.Error(t, r.Load([]byte(`{"a":`), nil))
+	assert.Equal(t, time.Second, r.Get("a").WarnDuration, "a failed load should keep the existing configs")
+
+	r.Set("b", &TimedLogConfig{WarnDuration: time.Hour})
+	path := filepath.Join(t.TempDir(), "timed.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"c":{"warn":"3s"}}`), 0o600))
+	assert.NoError(t, r.LoadFile(path))
+	assert.Equal(t, 3*time.Second, r.Get("c").WarnDuration)
+	assert.Equal(t, DefaultTimedLogConfig(), r.Get("a"), "reloading should replace every config")
+	assert.Equal(t, DefaultTimedLogConfig(), r.Get("b"))
+}
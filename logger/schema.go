@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"github.com/packaged/logger/v3/ld"
+	"go.uber.org/zap"
+)
+
+func init() {
+	ld.Declare("duration", ld.KindDuration, "time taken, see TimedLog")
+	ld.Declare("steps", ld.KindArray, "checkpoints and child timings, see TimedLog.Mark and TimedLog.Child")
+	ld.Declare("timings", ld.KindObject, "distribution of aggregated durations, see TimedLogConfig.AggregateInterval")
+	ld.Declare("still-running", ld.KindBool, "written by the TimedLog watchdog while an operation is running")
+	ld.Declare("elapsed", ld.KindDuration, "time since a running operation started")
+	ld.Declare("budget-remaining", ld.KindDuration, "time left until the context deadline when a timed operation completed")
+	ld.Declare("deadline-exceeded", ld.KindBool, "whether a timed operation completed after its context deadline")
+	ld.Declare("panic", ld.KindAny, "recovered panic value")
+}
+
+// SetSchema sets the schema field keys are checked against, nil disables checking.
+// Checks only run in development and test environments, where each entry with undeclared keys or values
+// of the wrong kind is followed by a DPanic entry, which panics in development.
+func (l *Logger) SetSchema(s *ld.Schema) {
+	l.schema = s
+}
+
+// schemaViolations returns the violations of the schema by the fields
+func (l *Logger) schemaViolations(fields []zap.Field) []string {
+	violations := l.schema.Check(fields)
+	if len(violations) == 0 {
+		return nil
+	}
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.Error()
+	}
+	return messages
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/packaged/environment/environment"
+	"github.com/packaged/logger/v3/ld"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger_SetSchema(t *testing.T) {
+	schema := ld.NewSchema()
+	schema.Declare("user-id", ld.KindString, "")
+
+	core, logs := observer.New(zap.DebugLevel)
+	l := &Logger{env: environment.UnitTest, zapper: zap.New(core)}
+	l.SetSchema(schema)
+
+	l.Info("valid", ld.UserID("u1"))
+	assert.Equal(t, 1, logs.Len(), "declared keys should not be reported")
+
+	l.AddCommon(zap.String("uid", "u2"))
+	l.Info("invalid", zap.Int("user-id", 1))
+	entries := logs.TakeAll()[1:]
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "invalid", entries[0].Message, "the entry should be written first")
+		assert.Equal(t, zapcore.DPanicLevel, entries[1].Level)
+		assert.Equal(t, "log field schema violation", entries[1].Message)
+		assert.Equal(t, "invalid", entries[1].ContextMap()["entry"])
+		assert.Equal(t, []any{`undeclared key "uid"`, `key "user-id" expects string, got int`}, entries[1].ContextMap()["violations"])
+	}
+}
+
+func TestLogger_SetSchema_Pipeline(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := &Logger{env: environment.Development, zapper: zap.New(core, zap.Development())}
+	l.SetSchema(ld.NewSchema())
+	l.SetScrubber(NewScrubber())
+	l.AddHook(HookFunc(func(e *Entry) bool {
+		e.Fields = append(e.Fields, zap.Bool("hooked", true))
+		return true
+	}))
+
+	assert.Panics(t, func() { l.Info("sent to bob@example.com", zap.String("to", "x")) }, "violations should panic in development")
+	entries := logs.TakeAll()
+	if assert.Len(t, entries, 2, "the entry should be written before the report panics") {
+		assert.Equal(t, "sent to [REDACTED:email]", entries[0].Message)
+		assert.Equal(t, "sent to [REDACTED:email]", entries[1].ContextMap()["entry"], "the report should be scrubbed")
+		assert.Equal(t, true, entries[1].ContextMap()["hooked"], "the report should run through the hooks")
+	}
+}
+
+func TestLogger_SetSchema_Production(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	l := &Logger{env: environment.Production, zapper: zap.New(core)}
+	l.SetSchema(ld.NewSchema())
+	l.Info("unchecked", zap.String("anything", "goes"))
+	assert.Equal(t, 1, logs.Len(), "schemas should only be checked in development and test environments")
+}
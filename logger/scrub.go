@@ -0,0 +1,260 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Scrubber replaces personally identifiable information embedded within messages and string field values.
+//
+// Unlike a Redactor, which acts on field keys, a Scrubber inspects the values themselves and is therefore
+// considerably more expensive; it is opt-in via Logger.SetScrubber.
+type Scrubber struct {
+	detectors []detector
+}
+
+type detector struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+	// locate returns the part of the match to replace, reporting false when there is none
+	locate func(match string) (start, end int, ok bool)
+}
+
+var builtinDetectors = []detector{
+	{name: "jwt", re: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+	{name: "bearer", re: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)},
+	{name: "api-key", re: regexp.MustCompile(`\b(?:AKIA[0-9A-Z]{16}|AIza[0-9A-Za-z_\-]{35}|(?:sk|pk|rk)_(?:live|test)_[0-9A-Za-z]{16,}|gh[pousr]_[A-Za-z0-9]{36,}|xox[abprs]-[A-Za-z0-9-]{10,}|glpat-[A-Za-z0-9_\-]{20,})\b`)},
+	{name: "email", re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)},
+	{name: "pan", re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhnValid},
+	// the whole colon separated word is matched, so identifiers such as std::string or Foo::Bar are not mistaken
+	// for an address embedded within them.  It runs before ipv4 so IPv4-mapped addresses are replaced whole.
+	{name: "ipv6", re: regexp.MustCompile(`[0-9A-Za-z_.]*:[0-9A-Za-z_.:]*`), locate: locateIPv6},
+	{name: "ipv4", re: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+}
+
+// locateIPv6 returns the IPv6 address making up a colon separated word, or following a label such as addr:,
+// ignoring trailing punctuation
+func locateIPv6(word string) (int, int, bool) {
+	end := len(strings.TrimRight(word, "."))
+	if end >= 2 && word[end-1] == ':' && word[end-2] != ':' {
+		end--
+	}
+	if validIPv6(word[:end]) {
+		return 0, end, true
+	}
+	if i := strings.IndexByte(word, ':'); i > 0 && i+1 < end && validIPv6(word[i+1:end]) {
+		return i + 1, end, true
+	}
+	return 0, 0, false
+}
+
+// validIPv6 reports whether the match is an IPv6 address.  The bare :: and addresses made only of single letter
+// groups, such as a::b, are more likely identifiers than addresses.
+func validIPv6(m string) bool {
+	if !strings.Contains(m, ":") || net.ParseIP(m) == nil {
+		return false
+	}
+	for _, group := range strings.Split(m, ":") {
+		if len(group) > 1 || strings.ContainsAny(group, "0123456789") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewScrubber creates a scrubber with the built-in detectors for JWTs, bearer tokens, common API key shapes,
+// email addresses, card numbers (PAN with a Luhn check) and IPv4/IPv6 addresses.
+func NewScrubber() *Scrubber {
+	s := &Scrubber{}
+	s.detectors = append(s.detectors, builtinDetectors...)
+	return s
+}
+
+// Register adds a user defined pattern, matches are replaced with [REDACTED:name].
+// When the pattern has a capturing group, only the first group is replaced, so surrounding context can be matched.
+func (s *Scrubber) Register(name string, re *regexp.Regexp) {
+	s.detectors = append(s.detectors, detector{name: name, re: re})
+}
+
+// SetScrubber sets the scrubber applied to log messages and string field values, nil disables scrubbing
+func (l *Logger) SetScrubber(s *Scrubber) {
+	l.scrubber = s
+}
+
+// Scrub returns the input with any detected PII replaced
+func (s *Scrubber) Scrub(input string) string {
+	for _, d := range s.detectors {
+		input = d.replace(input)
+	}
+	return input
+}
+
+// replace returns the input with valid matches, their first capturing group or the located part, replaced
+func (d detector) replace(input string) string {
+	matches := d.re.FindAllStringSubmatchIndex(input, -1)
+	if len(matches) == 0 {
+		return input
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 {
+			start, end = m[2], m[3]
+		}
+		if start < 0 || (d.valid != nil && !d.valid(input[start:end])) {
+			continue
+		}
+		if d.locate != nil {
+			from, to, ok := d.locate(input[start:end])
+			if !ok {
+				continue
+			}
+			start, end = start+from, start+to
+		}
+		b.WriteString(input[last:start])
+		b.WriteString("[REDACTED:" + d.name + "]")
+		last = end
+	}
+	if last == 0 {
+		return input
+	}
+	b.WriteString(input[last:])
+	return b.String()
+}
+
+// ScrubFields returns fields with PII replaced in string, stringer, byte string and error values,
+// including strings nested within objects and arrays.
+// The provided slice is not modified.
+func (s *Scrubber) ScrubFields(fields []zap.Field) []zap.Field {
+	var out []zap.Field
+	for i, f := range fields {
+		nf, changed := s.scrubField(f)
+		if changed && out == nil {
+			out = make([]zap.Field, len(fields))
+			copy(out, fields)
+		}
+		if changed {
+			out[i] = nf
+		}
+	}
+	if out == nil {
+		return fields
+	}
+	return out
+}
+
+func (s *Scrubber) scrubField(f zap.Field) (zap.Field, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		if scrubbed := s.Scrub(f.String); scrubbed != f.String {
+			f.String = scrubbed
+			return f, true
+		}
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			if scrubbed := s.Scrub(string(b)); scrubbed != string(b) {
+				return zap.ByteString(f.Key, []byte(scrubbed)), true
+			}
+		}
+	case zapcore.StringerType:
+		if str, ok := f.Interface.(fmt.Stringer); ok {
+			raw := str.String()
+			if scrubbed := s.Scrub(raw); scrubbed != raw {
+				return zap.String(f.Key, scrubbed), true
+			}
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			raw := err.Error()
+			if scrubbed := s.Scrub(raw); scrubbed != raw {
+				f.Interface = &scrubbedError{msg: scrubbed, err: err}
+				return f, true
+			}
+		}
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		return s.scrubNested(f)
+	}
+	return f, false
+}
+
+// scrubNested flattens complex values, such as error chains, into generic maps and slices so nested strings
+// can be scrubbed.  The original field is retained when nothing within it is replaced.
+func (s *Scrubber) scrubNested(f zap.Field) (zap.Field, bool) {
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	generic, err := toGeneric(enc.Fields[f.Key])
+	if err != nil {
+		return f, false
+	}
+	if scrubbed, changed := s.walk(generic); changed {
+		return zap.Any(f.Key, scrubbed), true
+	}
+	return f, false
+}
+
+func (s *Scrubber) walk(v any) (any, bool) {
+	switch val := v.(type) {
+	case string:
+		scrubbed := s.Scrub(val)
+		return scrubbed, scrubbed != val
+	case map[string]any:
+		changed := false
+		for k, nested := range val {
+			if nv, ok := s.walk(nested); ok {
+				val[k] = nv
+				changed = true
+			}
+		}
+		return val, changed
+	case []any:
+		changed := false
+		for i, nested := range val {
+			if nv, ok := s.walk(nested); ok {
+				val[i] = nv
+				changed = true
+			}
+		}
+		return val, changed
+	}
+	return v, false
+}
+
+// scrubbedError replaces the message of an error while retaining it for errors.Is and errors.As
+type scrubbedError struct {
+	msg string
+	err error
+}
+
+func (e *scrubbedError) Error() string { return e.msg }
+func (e *scrubbedError) Unwrap() error { return e.err }
+
+// luhnValid reports whether the digits within the input pass the Luhn checksum
+func luhnValid(input string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(input) - 1; i >= 0; i-- {
+		c := input[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+
+	"github.com/packaged/logger/v3/ld"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultStackTrimPrefixes are the function prefixes removed from error stacks by default
+var DefaultStackTrimPrefixes = []string{"runtime."}
+
+// SetStackTrimPrefixes sets the function prefixes, such as "net/http." or "github.com/acme/middleware.",
+// removed from stacks captured by ld.WithStack when they are printed, in addition to DefaultStackTrimPrefixes
+func (l *Logger) SetStackTrimPrefixes(prefixes ...string) {
+	l.stackTrim = prefixes
+}
+
+// errorStack returns the formatted stack of the first error field carrying one, or an empty string
+func (l *Logger) errorStack(fields []zap.Field) string {
+	for _, f := range fields {
+		if f.Type != zapcore.ErrorType {
+			continue
+		}
+		err, ok := f.Interface.(error)
+		if !ok {
+			continue
+		}
+		var st ld.StackTracer
+		if errors.As(err, &st) {
+			trim := make([]string, 0, len(DefaultStackTrimPrefixes)+len(l.stackTrim))
+			trim = append(append(trim, DefaultStackTrimPrefixes...), l.stackTrim...)
+			return formatStack(st.StackTrace(), trim)
+		}
+	}
+	return ""
+}
+
+// formatStack formats the program counters in the same format as zap stack traces, omitting any frames
+// whose function starts with one of the trim prefixes
+func formatStack(pcs []uintptr, trim []string) string {
+	b := &strings.Builder{}
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if !hasAnyPrefix(frame.Function, trim) {
+			writeFrame(b, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
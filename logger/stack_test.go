@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/packaged/logger/v3/ld"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func failingOperation() error { return ld.WithStack(errors.New("disk full")) }
+
+func TestLogger_ErrorStack(t *testing.T) {
+	cfg := zap.NewProductionConfig()
+	WithGoogleEncoding(&cfg)
+	buf := &bytes.Buffer{}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(cfg.EncoderConfig), zapcore.AddSync(buf), zap.DebugLevel)
+	l := &Logger{zapper: zap.New(core)}
+
+	err := failingOperation()
+	l.WarnIf(err, "warn")
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotContains(t, entry, "trace", "stacks should only be printed for error entries")
+
+	buf.Reset()
+	l.ErrorIf(err, "error")
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	trace := entry["trace"].(string)
+	assert.Regexp(t, `^github.com/packaged/logger/v3/logger.failingOperation\n\t.*stack_test.go:\d+\n`, trace)
+	assert.NotContains(t, trace, "runtime.goexit")
+	assert.Contains(t, trace, "testing.tRunner")
+
+	buf.Reset()
+	l.SetStackTrimPrefixes("testing.")
+	l.ErrorIf(err, "error")
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotContains(t, entry["trace"], "testing.tRunner")
+	assert.NotContains(t, entry["trace"], "runtime.goexit", "the default prefixes should still be trimmed")
+
+	buf.Reset()
+	l.ErrorIf(errors.New("no stack"), "error")
+	entry = nil
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.NotContains(t, entry, "trace")
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/packaged/environment/environment"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestingT is the subset of testing.TB used by the test helpers, so the testing package is not imported
+type TestingT interface {
+	Helper()
+	Log(args ...any)
+	Cleanup(func())
+}
+
+// NewTestLogger returns a logger for the test which does not touch the global instance, and the logs it
+// observes.  Entries are also written to t.Log, so they appear alongside the output of a failing test.
+// Pass the logger to the code under test with NewContext, which keeps parallel tests apart.
+func NewTestLogger(t TestingT) (*Logger, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := testCore(t)
+	l := &Logger{env: environment.UnitTest, zapper: zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2))}
+	return l, logs
+}
+
+// ObserveGlobal replaces the global logger for the duration of the test, returning the logs it observes.
+// Common fields and settings of the previous logger are kept, and it is restored when the test completes.
+// Tests observing the global logger must not run in parallel.
+func ObserveGlobal(t TestingT) *observer.ObservedLogs {
+	t.Helper()
+	prev := inst
+	if prev == nil {
+		l, logs := NewTestLogger(t)
+		Replace(l)
+		t.Cleanup(func() { Replace(nil) })
+		return logs
+	}
+
+	core, logs := testCore(t)
+	l := prev.Clone()
+	l.zapper = prev.zapper.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return core }))
+	prev.Sync()
+	Replace(l)
+	t.Cleanup(func() { Replace(prev) })
+	return logs
+}
+
+// testCore returns a core recording entries at every level, and writing them to t.Log until the test completes
+func testCore(t TestingT) (zapcore.Core, *observer.ObservedLogs) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	w := &testWriter{t: t}
+	t.Cleanup(w.close)
+	encoder := zapcore.NewConsoleEncoder(zap.NewDevelopmentEncoderConfig())
+	return zapcore.NewTee(observed, zapcore.NewCore(encoder, w, zapcore.DebugLevel)), logs
+}
+
+// testWriter writes to t.Log, dropping writes after the test completes, such as those of the TimedLog watchdog,
+// which would otherwise panic
+type testWriter struct {
+	mu     sync.Mutex
+	t      TestingT
+	closed bool
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (w *testWriter) Sync() error { return nil }
+
+func (w *testWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+}
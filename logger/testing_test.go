@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/packaged/environment/environment"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeT struct {
+	lines    []any
+	cleanups []func()
+}
+
+func (f *fakeT) Helper()           {}
+func (f *fakeT) Log(args ...any)   { f.lines = append(f.lines, args...) }
+func (f *fakeT) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func TestNewTestLogger(t *testing.T) {
+	ft := &fakeT{}
+	l, logs := NewTestLogger(ft)
+	prev := I()
+
+	l.Info("hello", zap.String("user", "alice"))
+	entries := logs.TakeAll()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "alice", entries[0].ContextMap()["user"])
+		assert.Contains(t, entries[0].Caller.File, "testing_test.go")
+	}
+	if assert.Len(t, ft.lines, 1) {
+		assert.Contains(t, ft.lines[0], "hello")
+	}
+	assert.Same(t, prev, I(), "the global logger should not be touched")
+
+	ft.finish()
+	l.Info("after")
+	assert.Len(t, ft.lines, 1, "entries after the test completes should not be written to the test log")
+	assert.Equal(t, 1, logs.Len())
+}
+
+func TestObserveGlobal(t *testing.T) {
+	assert.NoError(t, Setup(environment.UnitTest))
+	prev := I().Clone()
+	prev.AddCommon(zap.String("service", "api"))
+	Replace(prev)
+
+	t.Run("observe", func(t *testing.T) {
+		logs := ObserveGlobal(t)
+		I().AddCommon(zap.String("request", "1"))
+		I().Info("observed")
+
+		entries := logs.TakeAll()
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "api", entries[0].ContextMap()["service"], "common fields should be kept")
+			assert.Equal(t, "1", entries[0].ContextMap()["request"])
+			assert.Contains(t, entries[0].Caller.File, "testing_test.go")
+		}
+	})
+
+	assert.Same(t, prev, I(), "the global logger should be restored")
+	assert.Len(t, prev.common, 1, "the previous logger should not be modified")
+
+	Replace(nil)
+	t.Run("without global", func(t *testing.T) {
+		logs := ObserveGlobal(t)
+		I().Info("observed")
+		assert.Equal(t, 1, logs.Len())
+	})
+	assert.Nil(t, I())
+	assert.NoError(t, Setup(environment.UnitTest))
+}
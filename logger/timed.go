@@ -1,9 +1,16 @@
 package logger
 
 import (
+	"context"
+	"errors"
+	"math"
+	"runtime"
+	"sync"
 	"time"
 
+	"github.com/packaged/logger/v3/ld"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type TimedLogConfig struct {
@@ -11,6 +18,50 @@ type TimedLogConfig struct {
 	WarnDuration  time.Duration
 	InfoDuration  time.Duration
 	DebugDuration time.Duration
+
+	// AggregateInterval, when set, collects durations per message and writes a summary of their distribution
+	// at most once per interval, in place of individual entries below Warn.  See TimedLogConfig.Flush.
+	AggregateInterval time.Duration
+
+	// ErrorBudget, WarnBudget, InfoBudget and DebugBudget are thresholds as fractions of the time remaining until
+	// the context deadline when the timer starts.  For logs created with NewDeadlineLog, or StartTimer, with a
+	// context that has a deadline, they replace the matching duration threshold where set.
+	ErrorBudget float64
+	WarnBudget  float64
+	InfoBudget  float64
+	DebugBudget float64
+
+	// Watchdog writes an entry, with the elapsed time, as each of InfoDuration, WarnDuration and ErrorDuration
+	// is crossed while the operation is still running.  Logs created by NewLog write through I(), and those
+	// created by NewDeadlineLog or StartTimer through FromContext.
+	Watchdog bool
+
+	// Clock is the source of time for durations, aggregation and the watchdog, SystemClock if nil
+	Clock Clock
+
+	// Outcome returns the minimum level for an operation that failed with the error, DefaultOutcomeLevel if nil
+	Outcome func(err error) zapcore.Level
+}
+
+// DefaultOutcomeLevel logs operations cancelled through their context at Info, and any other failure at Warn
+func DefaultOutcomeLevel(err error) zapcore.Level {
+	if errors.Is(err, context.Canceled) {
+		return zapcore.InfoLevel
+	}
+	return zapcore.WarnLevel
+}
+
+func (c *TimedLogConfig) clock() Clock {
+	if c == nil || c.Clock == nil {
+		return SystemClock
+	}
+	return c.Clock
+}
+
+// Now returns the time of the config's Clock, for timing operations logged outside a TimedLog.
+// A nil config uses SystemClock.
+func (c *TimedLogConfig) Now() time.Time {
+	return c.clock().Now()
 }
 
 func DefaultTimedLogConfig() *TimedLogConfig {
@@ -21,20 +72,96 @@ func (c *TimedLogConfig) NewLog(message string, fields ...zap.Field) *TimedLog {
 	return NewTimedLog(c, message, fields...)
 }
 
+// NewDeadlineLog starts a timed log with thresholds relative to the deadline of the context, see NewDeadlineTimedLog
+func (c *TimedLogConfig) NewDeadlineLog(ctx context.Context, message string, fields ...zap.Field) *TimedLog {
+	return NewDeadlineTimedLog(ctx, c, message, fields...)
+}
+
+// TimedLog times an operation.  It is safe for concurrent use, and may be completed more than once; the first
+// completion is the one recorded.
 type TimedLog struct {
+	mu sync.Mutex
+
 	config   *TimedLogConfig
+	budgeted *TimedLogConfig
+	deadline time.Time
 	message  string
 	fields   []zap.Field
 	duration time.Duration
 	start    time.Time
 	complete bool
+	err      error
+	stop     chan struct{}
+
+	steps    []*timedStep
+	lastMark time.Duration
+}
+
+// timedStep is a checkpoint recorded by Mark, or a sub-timer created by Child
+type timedStep struct {
+	name     string
+	offset   time.Duration
+	duration time.Duration
+	child    *TimedLog
 }
 
+// Complete stops the timer, and any running child timers
 func (tl *TimedLog) Complete() {
+	tl.CompleteWithError(nil)
+}
+
+// CompleteWithError stops the timer, recording the outcome of the operation, only the first completion is recorded.
+// A non-nil error is logged with the entry, and escalates the level according to TimedLogConfig.Outcome.
+func (tl *TimedLog) CompleteWithError(err error) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
 	if !tl.complete {
-		tl.duration = time.Since(tl.start)
+		tl.duration = tl.config.clock().Now().Sub(tl.start)
+		tl.err = err
 		tl.complete = true
+		if tl.stop != nil {
+			close(tl.stop)
+		}
+		for _, step := range tl.steps {
+			if step.child != nil {
+				step.child.Complete()
+			}
+		}
+	}
+}
+
+// result returns the recorded duration and outcome
+func (tl *TimedLog) result() (time.Duration, error) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	return tl.duration, tl.err
+}
+
+// Mark records a checkpoint, as a step lasting from the previous checkpoint, or the start, until now.
+// Marks after Complete are ignored.
+func (tl *TimedLog) Mark(name string) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	if tl.complete {
+		return
 	}
+	now := tl.config.clock().Now().Sub(tl.start)
+	tl.steps = append(tl.steps, &timedStep{name: name, offset: tl.lastMark, duration: now - tl.lastMark})
+	tl.lastMark = now
+}
+
+// Child starts a sub-timer, recorded as a step lasting until the child is completed.
+// Children still running when the parent is completed are completed with it.
+func (tl *TimedLog) Child(name string) *TimedLog {
+	child := newTimedLog(tl.config, name, nil)
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	if !tl.complete {
+		tl.steps = append(tl.steps, &timedStep{name: name, offset: child.start.Sub(tl.start), child: child})
+	}
+	return child
 }
 
 var defaultTimedLogConfig = &TimedLogConfig{
@@ -45,7 +172,67 @@ var defaultTimedLogConfig = &TimedLogConfig{
 }
 
 func NewTimedLog(cnf *TimedLogConfig, message string, fields ...zap.Field) *TimedLog {
-	return &TimedLog{config: cnf, message: message, fields: fields, start: time.Now()}
+	tl := newTimedLog(cnf, message, fields)
+	if cnf.Watchdog {
+		tl.watch(I, zapcore.EntryCaller{})
+	}
+	return tl
+}
+
+// NewDeadlineTimedLog starts a timed log for an operation bounded by the deadline of the context.
+// Thresholds set as a fraction of the budget, the time remaining until the deadline, take the place of the
+// matching durations, and the entry records the budget remaining and whether the deadline was exceeded.
+// Without a deadline the log is the same as one created by NewTimedLog.
+func NewDeadlineTimedLog(ctx context.Context, cnf *TimedLogConfig, message string, fields ...zap.Field) *TimedLog {
+	tl := newDeadlineTimedLog(ctx, cnf, message, fields)
+	if cnf.Watchdog {
+		tl.watch(func() *Logger { return FromContext(ctx) }, zapcore.EntryCaller{})
+	}
+	return tl
+}
+
+func newTimedLog(cnf *TimedLogConfig, message string, fields []zap.Field) *TimedLog {
+	return &TimedLog{config: cnf, message: message, fields: fields, start: cnf.clock().Now()}
+}
+
+func newDeadlineTimedLog(ctx context.Context, cnf *TimedLogConfig, message string, fields []zap.Field) *TimedLog {
+	tl := newTimedLog(cnf, message, fields)
+	if deadline, ok := ctx.Deadline(); ok {
+		tl.deadline = deadline
+		tl.budgeted = cnf.withBudget(deadline.Sub(tl.start))
+	}
+	return tl
+}
+
+// thresholds returns the config the level is chosen by, with any deadline budget applied
+func (tl *TimedLog) thresholds() *TimedLogConfig {
+	if tl.budgeted != nil {
+		return tl.budgeted
+	}
+	return tl.config
+}
+
+// withBudget returns a copy of the config with the budget fractions applied to the duration thresholds
+func (c *TimedLogConfig) withBudget(budget time.Duration) *TimedLogConfig {
+	if budget < 0 {
+		budget = 0
+	}
+	levels := *c
+	for _, threshold := range []struct {
+		fraction float64
+		target   *time.Duration
+	}{
+		{c.ErrorBudget, &levels.ErrorDuration},
+		{c.WarnBudget, &levels.WarnDuration},
+		{c.InfoBudget, &levels.InfoDuration},
+		{c.DebugBudget, &levels.DebugDuration},
+	} {
+		if threshold.fraction > 0 {
+			// a zero threshold is disabled, an exhausted budget is crossed by any operation
+			*threshold.target = time.Duration(math.Max(1, threshold.fraction*float64(budget)))
+		}
+	}
+	return &levels
 }
 
 func (l *Logger) TimedLog(tl *TimedLog, fields ...zap.Field) {
@@ -54,22 +241,143 @@ func (l *Logger) TimedLog(tl *TimedLog, fields ...zap.Field) {
 	}
 
 	tl.Complete()
+	if lvl, ok := l.timedLevel(tl); ok {
+		l.log(lvl, tl.message, tl.logFields(fields))
+	}
+}
+
+// StartTimer starts timing an operation, returning a function that logs it through FromContext(ctx) with
+// the level chosen by the TimedLogConfig attached to the context, or DefaultTimedLogConfig.
+// Pass the address of an error to record the outcome, see TimedLog.CompleteWithError:
+//
+//	stop := logger.StartTimer(ctx, "db query", zap.String("table", "users"))
+//	defer stop(&err)
+//
+// Entries report the caller of StartTimer.  Thresholds are relative to the deadline of the context, as with
+// NewDeadlineTimedLog.
+func StartTimer(ctx context.Context, msg string, fields ...zap.Field) func(errs ...*error) {
+	tl := newDeadlineTimedLog(ctx, TimedLogConfigFromContext(ctx), msg, fields)
+	caller := timerCaller()
+	if tl.config.Watchdog {
+		tl.watch(func() *Logger { return FromContext(ctx) }, caller)
+	}
+	return func(errs ...*error) {
+		var failure error
+		for _, err := range errs {
+			if err != nil && *err != nil {
+				failure = *err
+				break
+			}
+		}
+		tl.CompleteWithError(failure)
 
-	nl := l.Clone()
-	nl.zapper = nl.zapper.WithOptions(zap.AddCallerSkip(1))
+		l := FromContext(ctx)
+		if l == nil {
+			return
+		}
+		if lvl, ok := l.timedLevel(tl); ok {
+			l.logWith(lvl, tl.message, tl.logFields(nil), func(ent *zapcore.Entry) {
+				if ent.Caller.Defined && caller.Defined {
+					ent.Caller = caller
+				}
+			})
+		}
+	}
+}
 
-	logFields := tl.fields
+// timerCaller returns the caller of StartTimer
+func timerCaller() zapcore.EntryCaller {
+	pc, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return zapcore.EntryCaller{}
+	}
+	caller := zapcore.EntryCaller{Defined: true, PC: pc, File: file, Line: line}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		caller.Function = fn.Name()
+	}
+	return caller
+}
+
+// logFields returns the fields of the completed log, followed by the provided fields, the duration, the
+// deadline budget and steps
+func (tl *TimedLog) logFields(fields []zap.Field) []zap.Field {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	logFields := make([]zap.Field, 0, len(tl.fields)+len(fields)+5)
 	logFields = append(logFields, tl.fields...)
 	logFields = append(logFields, fields...)
+	if tl.err != nil {
+		logFields = append(logFields, ld.Error(tl.err))
+	}
 	logFields = append(logFields, zap.Duration("duration", tl.duration))
+	if !tl.deadline.IsZero() {
+		remaining := tl.deadline.Sub(tl.start.Add(tl.duration))
+		if remaining < 0 {
+			remaining = 0
+		}
+		logFields = append(logFields, zap.Duration("budget-remaining", remaining), zap.Bool("deadline-exceeded", remaining == 0))
+	}
+	if len(tl.steps) > 0 {
+		logFields = append(logFields, zap.Array("steps", timedSteps(tl.steps)))
+	}
+	return logFields
+}
 
-	if tl.duration >= tl.config.ErrorDuration && tl.config.ErrorDuration > 0 {
-		nl.Error(tl.message, logFields...)
-	} else if tl.duration >= tl.config.WarnDuration && tl.config.WarnDuration > 0 {
-		nl.Warn(tl.message, logFields...)
-	} else if tl.duration >= tl.config.InfoDuration && tl.config.InfoDuration > 0 {
-		nl.Info(tl.message, logFields...)
-	} else if tl.duration >= tl.config.DebugDuration && tl.config.DebugDuration > 0 {
-		nl.Debug(tl.message, logFields...)
+// LevelFor returns the level for an operation of the provided duration and outcome, the greater of the
+// duration level and the Outcome level of a non-nil error.  Failed operations are always logged.
+func (c *TimedLogConfig) LevelFor(duration time.Duration, err error) (zapcore.Level, bool) {
+	lvl, ok := c.Level(duration)
+	if err == nil {
+		return lvl, ok
+	}
+	outcome := c.Outcome
+	if outcome == nil {
+		outcome = DefaultOutcomeLevel
+	}
+	if errLvl := outcome(err); !ok || errLvl > lvl {
+		lvl = errLvl
+	}
+	return lvl, true
+}
+
+// Level returns the level for an operation of the provided duration, or false if it should not be logged
+func (c *TimedLogConfig) Level(duration time.Duration) (zapcore.Level, bool) {
+	if duration >= c.ErrorDuration && c.ErrorDuration > 0 {
+		return zapcore.ErrorLevel, true
+	} else if duration >= c.WarnDuration && c.WarnDuration > 0 {
+		return zapcore.WarnLevel, true
+	} else if duration >= c.InfoDuration && c.InfoDuration > 0 {
+		return zapcore.InfoLevel, true
+	} else if duration >= c.DebugDuration && c.DebugDuration > 0 {
+		return zapcore.DebugLevel, true
+	}
+	return zapcore.DebugLevel, false
+}
+
+type timedSteps []*timedStep
+
+// MarshalLogArray implements zapcore.ArrayMarshaler
+func (s timedSteps) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, step := range s {
+		if err := enc.AppendObject(step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler
+func (s *timedStep) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", s.name)
+	enc.AddDuration("offset", s.offset)
+	if s.child == nil {
+		enc.AddDuration("duration", s.duration)
+		return nil
+	}
+	enc.AddDuration("duration", s.child.duration)
+	if len(s.child.steps) > 0 {
+		return enc.AddArray("steps", timedSteps(s.child.steps))
 	}
+	return nil
 }
@@ -1,11 +1,18 @@
 package logger
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
 	"github.com/packaged/environment/environment"
+	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"testing"
-	"time"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestTimedLog(t *testing.T) {
@@ -17,8 +24,11 @@ func TestTimedLog(t *testing.T) {
 		t.Errorf("expected no logs, got %d", logObs.Len())
 	}
 
-	tl := DefaultTimedLogConfig().NewLog("Doing something")
-	time.Sleep(time.Second)
+	clock := NewManualClock(time.Now())
+	cnf := *DefaultTimedLogConfig()
+	cnf.Clock = clock
+	tl := cnf.NewLog("Doing something")
+	clock.Advance(time.Second)
 	I().TimedLog(tl)
 	if logObs.Len() < 1 {
 		t.Errorf("expected 1 log, got %d", logObs.Len())
@@ -43,6 +53,8 @@ func TestTimedLogBreaks(t *testing.T) {
 		InfoDuration:  time.Millisecond * 10,
 		DebugDuration: time.Millisecond,
 	}
+	clock := NewManualClock(time.Now())
+	cnf.Clock = clock
 
 	tests := []struct {
 		name     string
@@ -61,7 +73,7 @@ func TestTimedLogBreaks(t *testing.T) {
 			Replace(testInst)
 			logObs := ObserverForTest()
 			timedL := cnf.NewLog("abc")
-			time.Sleep(test.duration + time.Millisecond)
+			clock.Advance(test.duration)
 			I().TimedLog(timedL)
 			if logObs.Len() != 1 {
 				t.Errorf("expected 1 log, got %d", logObs.Len())
@@ -73,3 +85,366 @@ func TestTimedLogBreaks(t *testing.T) {
 		})
 	}
 }
+
+func TestTimedLogFields(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+
+	clock := NewManualClock(time.Now())
+	tl := (&TimedLogConfig{DebugDuration: time.Nanosecond, Clock: clock}).NewLog("abc", zap.String("table", "users"))
+	clock.Advance(time.Millisecond)
+	l.TimedLog(tl, zap.Int("rows", 1))
+
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 1)
+	var keys []string
+	for _, f := range logs[0].Context {
+		keys = append(keys, f.Key)
+	}
+	assert.Equal(t, []string{"table", "rows", "duration"}, keys)
+}
+
+func TestTimedLogSteps(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+
+	clock := NewManualClock(time.Now())
+	tl := (&TimedLogConfig{DebugDuration: time.Nanosecond, Clock: clock}).NewLog("import")
+	clock.Advance(2 * time.Millisecond)
+	tl.Mark("fetched")
+	parse := tl.Child("parse")
+	clock.Advance(3 * time.Millisecond)
+	parse.Mark("tokenised")
+	parse.Complete()
+	running := tl.Child("write")
+	clock.Advance(time.Millisecond)
+	tl.Mark("parsed")
+	l.TimedLog(tl)
+	tl.Mark("ignored")
+
+	logs := observedLogs.TakeAll()
+	if !assert.Len(t, logs, 1) {
+		return
+	}
+	steps, ok := logs[0].ContextMap()["steps"].([]any)
+	if !assert.True(t, ok) || !assert.Len(t, steps, 4) {
+		return
+	}
+
+	fetched := steps[0].(map[string]any)
+	assert.Equal(t, "fetched", fetched["name"])
+	assert.Equal(t, time.Duration(0), fetched["offset"])
+	assert.Equal(t, 2*time.Millisecond, fetched["duration"])
+
+	child := steps[1].(map[string]any)
+	assert.Equal(t, "parse", child["name"])
+	assert.Equal(t, fetched["duration"], child["offset"])
+	assert.Equal(t, 3*time.Millisecond, child["duration"])
+	assert.Equal(t, "tokenised", child["steps"].([]any)[0].(map[string]any)["name"])
+
+	assert.Equal(t, "write", steps[2].(map[string]any)["name"])
+	assert.Equal(t, time.Millisecond, steps[2].(map[string]any)["duration"])
+	assert.True(t, running.complete, "running children should be completed with the parent")
+
+	parsed := steps[3].(map[string]any)
+	assert.Equal(t, "parsed", parsed["name"])
+	assert.Equal(t, fetched["duration"], parsed["offset"], "marks should start at the previous mark")
+	assert.Equal(t, zapcore.DebugLevel, logs[0].Level, "the level should be chosen from the total duration")
+}
+
+func TestStartTimer(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l, err := InstanceWithConfig(environment.UnitTest, zap.NewDevelopmentConfig())
+	assert.NoError(t, err)
+	l.zapper = l.zapper.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return observedZapCore }))
+
+	ctx := NewContext(context.Background(), l)
+	clock := NewManualClock(time.Now())
+	ctx = WithTimedLogConfig(ctx, &TimedLogConfig{WarnDuration: time.Millisecond, DebugDuration: time.Nanosecond, Clock: clock})
+
+	func() {
+		defer StartTimer(ctx, "quick", zap.String("table", "users"))()
+		clock.Advance(time.Microsecond)
+	}()
+	func() {
+		stop := StartTimer(ctx, "slow")
+		defer stop()
+		clock.Advance(2 * time.Millisecond)
+	}()
+	failed := func() (err error) {
+		defer StartTimer(ctx, "failed")(&err)
+		clock.Advance(time.Microsecond)
+		return errors.New("query failed")
+	}
+	assert.Error(t, failed())
+	succeeded := func() (err error) {
+		defer StartTimer(ctx, "succeeded")(&err)
+		clock.Advance(time.Microsecond)
+		return nil
+	}
+	assert.NoError(t, succeeded())
+
+	logs := observedLogs.TakeAll()
+	if !assert.Len(t, logs, 4) {
+		return
+	}
+	assert.Equal(t, zapcore.DebugLevel, logs[0].Level)
+	assert.Equal(t, "users", logs[0].ContextMap()["table"])
+	assert.Contains(t, logs[0].ContextMap(), "duration")
+	assert.Equal(t, zapcore.WarnLevel, logs[1].Level)
+	assert.Equal(t, zapcore.WarnLevel, logs[2].Level, "failures should be at least Warn")
+	assert.Equal(t, "query failed", logs[2].ContextMap()["error"])
+	assert.Equal(t, zapcore.DebugLevel, logs[3].Level)
+	for _, entry := range logs {
+		assert.Contains(t, entry.Caller.File, "timed_test.go", entry.Message)
+		assert.Contains(t, entry.Caller.Function, "TestStartTimer", entry.Message)
+	}
+}
+
+func TestTimedLogOutcome(t *testing.T) {
+	cnf := &TimedLogConfig{ErrorDuration: time.Minute, InfoDuration: time.Second}
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name     string
+		outcome  func(error) zapcore.Level
+		duration time.Duration
+		err      error
+		level    zapcore.Level
+		logged   bool
+	}{
+		{"fast success", nil, time.Millisecond, nil, zapcore.DebugLevel, false},
+		{"fast failure", nil, time.Millisecond, failed, zapcore.WarnLevel, true},
+		{"slow failure", nil, time.Hour, failed, zapcore.ErrorLevel, true},
+		{"cancelled", nil, time.Millisecond, fmt.Errorf("query: %w", context.Canceled), zapcore.InfoLevel, true},
+		{"deadline", nil, time.Millisecond, context.DeadlineExceeded, zapcore.WarnLevel, true},
+		{"custom", func(error) zapcore.Level { return zapcore.ErrorLevel }, time.Millisecond, failed, zapcore.ErrorLevel, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := *cnf
+			cfg.Outcome = test.outcome
+			lvl, ok := cfg.LevelFor(test.duration, test.err)
+			assert.Equal(t, test.logged, ok)
+			if ok {
+				assert.Equal(t, test.level, lvl)
+			}
+		})
+	}
+}
+
+func TestTimedLogCompleteWithError(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+
+	tl := (&TimedLogConfig{InfoDuration: time.Hour}).NewLog("op")
+	tl.CompleteWithError(errors.New("failed"))
+	tl.CompleteWithError(nil)
+	l.TimedLog(tl)
+
+	logs := observedLogs.TakeAll()
+	if assert.Len(t, logs, 1, "a fast failure should still be logged") {
+		assert.Equal(t, zapcore.WarnLevel, logs[0].Level)
+		assert.Equal(t, "failed", logs[0].ContextMap()["error"], "a later nil outcome should not clear the error")
+	}
+}
+
+func TestTimedLogWatchdog(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	clock := NewManualClock(time.Now())
+	cnf := &TimedLogConfig{InfoDuration: 5 * time.Millisecond, WarnDuration: 10 * time.Millisecond, Watchdog: true, Clock: clock}
+
+	tl := newTimedLog(cnf, "long", []zap.Field{zap.String("job", "import")})
+	tl.Watch(l)
+	clock.Advance(6 * time.Millisecond)
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(6 * time.Millisecond)
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 2 }, time.Second, time.Millisecond)
+	tl.Complete()
+
+	logs := observedLogs.TakeAll()
+	if assert.Len(t, logs, 2) {
+		for i, lvl := range []zapcore.Level{zapcore.InfoLevel, zapcore.WarnLevel} {
+			assert.Equal(t, lvl, logs[i].Level)
+			assert.Equal(t, "long", logs[i].Message)
+			assert.Equal(t, "import", logs[i].ContextMap()["job"], "the original fields should be logged")
+			assert.Equal(t, true, logs[i].ContextMap()["still-running"])
+			assert.Equal(t, time.Duration(i+1)*6*time.Millisecond, logs[i].ContextMap()["elapsed"])
+		}
+	}
+
+	tl = newTimedLog(cnf, "quick", nil)
+	tl.Watch(l)
+	tl.Complete()
+	clock.Advance(time.Minute)
+	assert.Zero(t, observedLogs.Len(), "a completed log should stop the watchdog")
+}
+
+func TestStartTimerWatchdog(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l, err := InstanceWithConfig(environment.UnitTest, zap.NewDevelopmentConfig())
+	assert.NoError(t, err)
+	l.zapper = l.zapper.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core { return observedZapCore }))
+
+	ctx := NewContext(context.Background(), l)
+	clock := NewManualClock(time.Now())
+	ctx = WithTimedLogConfig(ctx, &TimedLogConfig{InfoDuration: 5 * time.Millisecond, Watchdog: true, Clock: clock})
+
+	stop := StartTimer(ctx, "long")
+	clock.Advance(5 * time.Millisecond)
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 1 }, time.Second, time.Millisecond)
+	stop()
+
+	logs := observedLogs.TakeAll()
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, true, logs[0].ContextMap()["still-running"])
+		assert.NotContains(t, logs[1].ContextMap(), "still-running")
+		for _, entry := range logs {
+			assert.Contains(t, entry.Caller.Function, "TestStartTimerWatchdog", entry.Message)
+		}
+	}
+}
+
+func TestDeadlineTimedLogWatchdog(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	global := ObserveGlobal(t)
+
+	clock := NewManualClock(time.Now())
+	cnf := &TimedLogConfig{InfoDuration: 5 * time.Millisecond, Watchdog: true, Clock: clock}
+	tl := cnf.NewDeadlineLog(NewContext(context.Background(), l), "long")
+	clock.Advance(5 * time.Millisecond)
+	assert.Eventually(t, func() bool { return observedLogs.Len() == 1 }, time.Second, time.Millisecond,
+		"the watchdog should write through the logger of the context")
+	tl.Complete()
+	assert.Zero(t, global.Len())
+}
+
+func TestTimedLogDeadline(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	clock := NewManualClock(time.Now())
+	cnf := &TimedLogConfig{ErrorBudget: 1, WarnBudget: 0.5, InfoDuration: time.Hour, DebugDuration: time.Nanosecond, Clock: clock}
+
+	ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(time.Hour))
+	defer cancel()
+	tl := cnf.NewDeadlineLog(ctx, "within budget")
+	clock.Advance(time.Minute)
+	l.TimedLog(tl)
+
+	ctx, cancel = context.WithDeadline(context.Background(), clock.Now().Add(20*time.Second))
+	defer cancel()
+	tl = cnf.NewDeadlineLog(ctx, "over half")
+	clock.Advance(12 * time.Second)
+	l.TimedLog(tl)
+
+	tl = cnf.NewDeadlineLog(ctx, "exceeded")
+	clock.Advance(10 * time.Second)
+	l.TimedLog(tl)
+
+	tl = cnf.NewDeadlineLog(context.Background(), "no deadline")
+	clock.Advance(time.Second)
+	l.TimedLog(tl)
+
+	logs := observedLogs.TakeAll()
+	if !assert.Len(t, logs, 4) {
+		return
+	}
+	assert.Equal(t, zapcore.DebugLevel, logs[0].Level)
+	assert.Equal(t, false, logs[0].ContextMap()["deadline-exceeded"])
+	assert.Equal(t, 59*time.Minute, logs[0].ContextMap()["budget-remaining"])
+
+	assert.Equal(t, zapcore.WarnLevel, logs[1].Level, "using over half the budget should warn")
+	assert.Equal(t, false, logs[1].ContextMap()["deadline-exceeded"])
+	assert.Equal(t, 8*time.Second, logs[1].ContextMap()["budget-remaining"])
+
+	assert.Equal(t, zapcore.ErrorLevel, logs[2].Level, "exceeding the deadline should error")
+	assert.Equal(t, true, logs[2].ContextMap()["deadline-exceeded"])
+	assert.Equal(t, time.Duration(0), logs[2].ContextMap()["budget-remaining"])
+
+	assert.Equal(t, zapcore.DebugLevel, logs[3].Level)
+	assert.NotContains(t, logs[3].ContextMap(), "budget-remaining", "without a deadline there is no budget")
+	assert.NotContains(t, logs[3].ContextMap(), "deadline-exceeded")
+}
+
+func TestTimedLogConfigWithBudget(t *testing.T) {
+	cnf := &TimedLogConfig{ErrorBudget: 0.9, WarnBudget: 0.5, WarnDuration: time.Minute, InfoDuration: time.Second}
+
+	levels := cnf.withBudget(10 * time.Second)
+	assert.Equal(t, 9*time.Second, levels.ErrorDuration)
+	assert.Equal(t, 5*time.Second, levels.WarnDuration, "fractions should replace durations")
+	assert.Equal(t, time.Second, levels.InfoDuration, "durations without a fraction should be kept")
+	assert.Equal(t, time.Minute, cnf.WarnDuration, "the config should not be modified")
+
+	levels = cnf.withBudget(-time.Second)
+	lvl, ok := levels.Level(time.Nanosecond)
+	assert.True(t, ok)
+	assert.Equal(t, zapcore.ErrorLevel, lvl, "an exhausted budget should be crossed by any operation")
+}
+
+func TestTimedLogConcurrentComplete(t *testing.T) {
+	observedZapCore, observedLogs := observer.New(zap.DebugLevel)
+	l := &Logger{zapper: zap.New(observedZapCore)}
+	clock := NewManualClock(time.Now())
+	cnf := &TimedLogConfig{DebugDuration: time.Nanosecond, Watchdog: true, InfoDuration: time.Hour, Clock: clock}
+
+	tl := newTimedLog(cnf, "shared", nil)
+	tl.Watch(l)
+	child := tl.Child("child")
+	clock.Advance(time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			tl.Complete()
+		}()
+		go func() {
+			defer wg.Done()
+			tl.Mark("mark")
+			child.CompleteWithError(errors.New("failed"))
+		}()
+		go func() {
+			defer wg.Done()
+			l.TimedLog(tl)
+		}()
+	}
+	wg.Wait()
+	clock.Advance(time.Second)
+
+	logs := observedLogs.TakeAll()
+	assert.Len(t, logs, 8)
+	for _, entry := range logs {
+		assert.Equal(t, time.Second, entry.ContextMap()["duration"], "the first completion should be recorded")
+	}
+}
+
+func TestTimedLogConcurrentCompleteWithError(t *testing.T) {
+	cnf := &TimedLogConfig{DebugDuration: time.Nanosecond, Clock: NewManualClock(time.Now())}
+	errs := []error{errors.New("first"), errors.New("second"), nil}
+	for i := 0; i < 20; i++ {
+		tl := newTimedLog(cnf, "raced", nil)
+		var wg sync.WaitGroup
+		for _, err := range errs {
+			wg.Add(1)
+			go func(err error) {
+				defer wg.Done()
+				tl.CompleteWithError(err)
+			}(err)
+		}
+		wg.Wait()
+		tl.CompleteWithError(errors.New("late"))
+
+		_, err := tl.result()
+		assert.NotEqual(t, "late", fmt.Sprint(err), "a later completion should not replace the outcome")
+	}
+
+	tl := newTimedLog(cnf, "ordered", nil)
+	tl.CompleteWithError(errors.New("first"))
+	tl.CompleteWithError(errors.New("second"))
+	tl.Complete()
+	_, err := tl.result()
+	assert.EqualError(t, err, "first", "only the first completion should be recorded")
+}
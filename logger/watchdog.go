@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Watch starts a watchdog writing through l while the operation is running, see TimedLogConfig.Watchdog.
+// The watchdog stops when the log is completed.
+func (tl *TimedLog) Watch(l *Logger) {
+	tl.watch(func() *Logger { return l }, zapcore.EntryCaller{})
+}
+
+// watch starts a goroutine writing a "still running" entry through the resolved logger as each threshold is
+// crossed, until the log is completed or every threshold has passed
+func (tl *TimedLog) watch(logger func() *Logger, caller zapcore.EntryCaller) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	if tl.complete || tl.stop != nil {
+		return
+	}
+	thresholds := tl.thresholds().watchdogThresholds()
+	if len(thresholds) == 0 {
+		return
+	}
+
+	clock := tl.config.clock()
+	tl.stop = make(chan struct{})
+	go func(stop <-chan struct{}) {
+		for _, th := range thresholds {
+			timer := clock.NewTimer(th.after - clock.Now().Sub(tl.start))
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C():
+			}
+			select {
+			case <-stop:
+				// completed as the threshold was crossed
+				return
+			default:
+			}
+
+			l := logger()
+			if l == nil {
+				continue
+			}
+			fields := make([]zap.Field, 0, len(tl.fields)+2)
+			fields = append(fields, tl.fields...)
+			fields = append(fields, zap.Bool("still-running", true), zap.Duration("elapsed", clock.Now().Sub(tl.start)))
+			l.logWith(th.level, tl.message, fields, func(ent *zapcore.Entry) {
+				// the watchdog goroutine is not a useful caller
+				ent.Caller = caller
+			})
+		}
+	}(tl.stop)
+}
+
+type watchdogThreshold struct {
+	after time.Duration
+	level zapcore.Level
+}
+
+// watchdogThresholds returns the Info, Warn and Error thresholds that are set, in ascending order
+func (c *TimedLogConfig) watchdogThresholds() []watchdogThreshold {
+	var thresholds []watchdogThreshold
+	for _, th := range []watchdogThreshold{
+		{c.InfoDuration, zapcore.InfoLevel},
+		{c.WarnDuration, zapcore.WarnLevel},
+		{c.ErrorDuration, zapcore.ErrorLevel},
+	} {
+		if th.after <= 0 {
+			continue
+		}
+		if n := len(thresholds); n > 0 && th.after <= thresholds[n-1].after {
+			// a lower threshold would be reached first, and no entry is written at its level
+			continue
+		}
+		thresholds = append(thresholds, th)
+	}
+	return thresholds
+}
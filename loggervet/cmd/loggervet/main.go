@@ -0,0 +1,12 @@
+// Command loggervet reports misuse of the packaged logger, see the loggervet package.
+//
+//	go install github.com/packaged/logger/v3/loggervet/cmd/loggervet@latest
+//	loggervet ./...
+package main
+
+import (
+	"github.com/packaged/logger/v3/loggervet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() { singlechecker.Main(loggervet.Analyzer) }
@@ -0,0 +1,11 @@
+// loggervet requires Go 1.25, the minimum of its golang.org/x/tools release, while the logger itself supports Go 1.20.
+module github.com/packaged/logger/v3/loggervet
+
+go 1.25.0
+
+require golang.org/x/tools v0.47.0
+
+require (
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+)
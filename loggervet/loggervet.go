@@ -0,0 +1,338 @@
+// Package loggervet provides an analyzer reporting common misuse of the logger and ld packages:
+// ErrorIf style calls with a nil error, duplicate field keys within a single call, AddCommon on the global
+// logger, and timed logs that are never completed or logged.
+package loggervet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const (
+	loggerPath = "github.com/packaged/logger/v3/logger"
+	ldPath     = "github.com/packaged/logger/v3/ld"
+	zapPath    = "go.uber.org/zap"
+)
+
+// Analyzer reports misuse of logger.Logger and ld call sites
+var Analyzer = &analysis.Analyzer{
+	Name: "loggervet",
+	Doc: `report misuse of the packaged logger
+
+Reports ErrorIf, WarnIf and the other *If methods called with a nil error, which never log;
+fields with the same key passed to a single call; AddCommon called on the global logger.I() instance,
+which adds the fields to every entry written by every caller; and timed logs that are created but
+never completed or logged.`,
+	Run: run,
+}
+
+// conditionalMethods are the Logger methods that only log when the error is not nil
+var conditionalMethods = map[string]bool{"DebugIf": true, "InfoIf": true, "WarnIf": true, "ErrorIf": true, "FatalIf": true}
+
+// ldKeys are the keys of the ld helpers that do not take a key argument
+var ldKeys = map[string]string{
+	"IP":          "ip",
+	"UserAgent":   "user-agent",
+	"URL":         "url",
+	"PortString":  "port",
+	"Port":        "port",
+	"Method":      "method",
+	"Error":       "error",
+	"RequestID":   "request-id",
+	"TraceID":     "trace-id",
+	"UserID":      "user-id",
+	"TenantID":    "tenant-id",
+	"StatusCode":  "status",
+	"Latency":     "latency",
+	"BytesIn":     "bytes-in",
+	"BytesOut":    "bytes-out",
+	"Host":        "host",
+	"Path":        "path",
+	"Query":       "query",
+	"Referer":     "referer",
+	"ContentType": "content-type",
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		var timedLogs []*types.Var
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				checkCall(pass, n)
+			case *ast.ExprStmt:
+				if call, ok := unparen(n.X).(*ast.CallExpr); ok && isNewTimedLog(pass.TypesInfo, call) {
+					pass.Reportf(call.Pos(), "timed log is discarded, it will never be completed or logged")
+				}
+			case *ast.AssignStmt:
+				if n.Tok == token.DEFINE {
+					timedLogs = append(timedLogs, timedLogVars(pass.TypesInfo, n.Lhs, n.Rhs)...)
+				}
+			case *ast.ValueSpec:
+				idents := make([]ast.Expr, len(n.Names))
+				for i, name := range n.Names {
+					idents[i] = name
+				}
+				timedLogs = append(timedLogs, timedLogVars(pass.TypesInfo, idents, n.Values)...)
+			}
+			return true
+		})
+		for _, v := range timedLogs {
+			if !timedLogHandled(pass.TypesInfo, file, v) {
+				pass.Reportf(v.Pos(), "timed log %s is never completed or logged", v.Name())
+			}
+		}
+	}
+	return nil, nil
+}
+
+func checkCall(pass *analysis.Pass, call *ast.CallExpr) {
+	fn := callee(pass.TypesInfo, call)
+	if fn == nil || fn.Pkg() == nil {
+		return
+	}
+	pkg := fn.Pkg().Path()
+	if pkg != loggerPath && pkg != ldPath {
+		return
+	}
+
+	if isLoggerMethod(fn) {
+		switch {
+		case conditionalMethods[fn.Name()] && len(call.Args) > 0 && pass.TypesInfo.Types[call.Args[0]].IsNil():
+			pass.Reportf(call.Pos(), "%s called with a nil error never logs", fn.Name())
+		case fn.Name() == "AddCommon" && isGlobalLogger(pass.TypesInfo, call):
+			pass.Reportf(call.Pos(), "AddCommon on the global logger adds fields to every entry, use a Clone")
+		}
+	}
+
+	checkDuplicateKeys(pass, fn, call)
+}
+
+// checkDuplicateKeys reports fields passed to the variadic ...zap.Field parameter with a key already used
+func checkDuplicateKeys(pass *analysis.Pass, fn *types.Func, call *ast.CallExpr) {
+	sig := fn.Type().(*types.Signature)
+	if !sig.Variadic() || call.Ellipsis.IsValid() {
+		return
+	}
+	last := sig.Params().At(sig.Params().Len() - 1).Type().(*types.Slice)
+	if !isNamed(last.Elem(), zapPath, "Field") {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, arg := range call.Args[sig.Params().Len()-1:] {
+		key, ok := fieldKey(pass.TypesInfo, arg)
+		if !ok {
+			continue
+		}
+		if seen[key] {
+			pass.Reportf(arg.Pos(), "duplicate field key %q", key)
+		}
+		seen[key] = true
+	}
+}
+
+// fieldKey returns the key of a field created by a zap or ld function, when it is known at compile time
+func fieldKey(info *types.Info, expr ast.Expr) (string, bool) {
+	call, ok := unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return "", false
+	}
+	fn := callee(info, call)
+	if fn == nil || fn.Pkg() == nil || fn.Type().(*types.Signature).Recv() != nil {
+		return "", false
+	}
+
+	switch fn.Pkg().Path() {
+	case zapPath:
+		if fn.Name() == "Error" {
+			return "error", true
+		}
+	case ldPath:
+		if key, ok := ldKeys[fn.Name()]; ok {
+			return key, true
+		}
+		switch fn.Name() {
+		case "Prefix":
+			if len(call.Args) != 2 {
+				return "", false
+			}
+			prefix, ok := constantString(info, call.Args[0])
+			if !ok {
+				return "", false
+			}
+			key, ok := fieldKey(info, call.Args[1])
+			return prefix + ":" + key, ok
+		case "InterfaceType":
+			if len(call.Args) == 0 {
+				return "", false
+			}
+			key, ok := constantString(info, call.Args[0])
+			return key + ":type", ok
+		}
+	default:
+		return "", false
+	}
+
+	// any other helper taking the key as its first argument
+	params := fn.Type().(*types.Signature).Params()
+	if params.Len() == 0 || len(call.Args) == 0 || !isKeyParam(params.At(0)) {
+		return "", false
+	}
+	return constantString(info, call.Args[0])
+}
+
+func isKeyParam(p *types.Var) bool {
+	basic, ok := p.Type().(*types.Basic)
+	return ok && basic.Kind() == types.String && (p.Name() == "key" || p.Name() == "name")
+}
+
+func constantString(info *types.Info, expr ast.Expr) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// isGlobalLogger reports whether the receiver of the method call is logger.I()
+func isGlobalLogger(info *types.Info, call *ast.CallExpr) bool {
+	sel, ok := unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	recv, ok := unparen(sel.X).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn := callee(info, recv)
+	return fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == loggerPath && fn.Name() == "I"
+}
+
+// isNewTimedLog reports whether the call creates a logger.TimedLog
+func isNewTimedLog(info *types.Info, call *ast.CallExpr) bool {
+	fn := callee(info, call)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != loggerPath {
+		return false
+	}
+	results := fn.Type().(*types.Signature).Results()
+	return results.Len() == 1 && isNamed(results.At(0).Type(), loggerPath, "TimedLog")
+}
+
+// timedLogVars returns the variables assigned a newly created timed log
+func timedLogVars(info *types.Info, lhs, rhs []ast.Expr) []*types.Var {
+	if len(lhs) != len(rhs) {
+		return nil
+	}
+	var vars []*types.Var
+	for i, expr := range rhs {
+		call, ok := unparen(expr).(*ast.CallExpr)
+		if !ok || !isNewTimedLog(info, call) {
+			continue
+		}
+		if ident, ok := lhs[i].(*ast.Ident); ok {
+			if v, ok := info.Defs[ident].(*types.Var); ok {
+				vars = append(vars, v)
+			}
+		}
+	}
+	return vars
+}
+
+// timedLogHandled reports whether the timed log is completed, logged, or passed elsewhere which may do so
+func timedLogHandled(info *types.Info, file *ast.File, v *types.Var) bool {
+	handled := false
+	var stack []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if handled {
+			return false
+		}
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if ident, ok := n.(*ast.Ident); ok && info.Uses[ident] == v {
+			handled = handledUse(ident, stack[len(stack)-1])
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return handled
+}
+
+func handledUse(ident *ast.Ident, parent ast.Node) bool {
+	switch p := parent.(type) {
+	case *ast.SelectorExpr:
+		// tl.Complete() or tl.CompleteWithError(err), other methods or fields do not complete the log
+		return p.Sel.Name == "Complete" || p.Sel.Name == "CompleteWithError"
+	case *ast.AssignStmt:
+		for _, lhs := range p.Lhs {
+			if lhs == ident {
+				// reassigned, not read
+				return false
+			}
+		}
+		for i, rhs := range p.Rhs {
+			if rhs == ident && len(p.Lhs) == len(p.Rhs) && isBlank(p.Lhs[i]) {
+				// discarded
+				return false
+			}
+		}
+	}
+	// passed to Logger.TimedLog, or escaping to code that may complete it
+	return true
+}
+
+// callee returns the function or method called, or nil for calls of function values, builtins and conversions
+func callee(info *types.Info, call *ast.CallExpr) *types.Func {
+	var obj types.Object
+	switch fun := unparen(call.Fun).(type) {
+	case *ast.Ident:
+		obj = info.Uses[fun]
+	case *ast.SelectorExpr:
+		if sel, ok := info.Selections[fun]; ok {
+			obj = sel.Obj()
+		} else {
+			obj = info.Uses[fun.Sel]
+		}
+	}
+	fn, _ := obj.(*types.Func)
+	return fn
+}
+
+func isLoggerMethod(fn *types.Func) bool {
+	recv := fn.Type().(*types.Signature).Recv()
+	return recv != nil && isNamed(recv.Type(), loggerPath, "Logger")
+}
+
+// isNamed reports whether t, or the type it points to, is the named type pkg.name
+func isNamed(t types.Type, pkg, name string) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == pkg
+}
+
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+func isBlank(e ast.Expr) bool {
+	ident, ok := e.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
@@ -0,0 +1,65 @@
+package a
+
+import (
+	"errors"
+
+	"github.com/packaged/logger/v3/ld"
+	"github.com/packaged/logger/v3/logger"
+	"go.uber.org/zap"
+)
+
+func conditional(l *logger.Logger, err error) {
+	l.ErrorIf(nil, "failed") // want `ErrorIf called with a nil error never logs`
+	l.WarnIf(nil, "failed")  // want `WarnIf called with a nil error never logs`
+	l.ErrorIf(err, "failed")
+	l.ErrorIf(errors.New("x"), "failed")
+}
+
+func duplicates(l *logger.Logger, key string) {
+	l.Info("dup",
+		zap.String("id", "a"),
+		zap.Int("id", 1), // want `duplicate field key "id"`
+		ld.IP("127.0.0.1"),
+		zap.String("ip", "b"), // want `duplicate field key "ip"`
+		ld.Prefix("req", ld.UserID("u")),
+		ld.Prefix("req", zap.String("user-id", "u")), // want `duplicate field key "req:user-id"`
+		zap.Error(nil),
+		zap.Any("error", nil), // want `duplicate field key "error"`
+		zap.String(key, "dynamic"),
+		zap.String(key, "dynamic"),
+	)
+	l.AddCommon(ld.Secret("token", "t"), ld.Secret("token", "t"))         // want `duplicate field key "token"`
+	_ = ld.Group("g", ld.InterfaceType("h", l), zap.String("h:type", "")) // want `duplicate field key "h:type"`
+	l.Info("distinct", zap.String("a", ""), zap.String("b", ""), ld.Group("a:b"))
+	fields := []zap.Field{zap.String("id", ""), zap.String("id", "")}
+	l.Info("spread", fields...)
+}
+
+func global() {
+	logger.I().AddCommon(zap.String("a", "b")) // want `AddCommon on the global logger adds fields to every entry, use a Clone`
+	l := logger.I().Clone()
+	l.AddCommon(zap.String("a", "b"))
+}
+
+func timed(l *logger.Logger, cfg *logger.TimedLogConfig) *logger.TimedLog {
+	completed := cfg.NewLog("completed")
+	completed.Complete()
+
+	failed := cfg.NewLog("failed")
+	failed.CompleteWithError(nil)
+
+	logged := cfg.NewLog("logged")
+	defer l.TimedLog(logged)
+
+	forgotten := logger.DefaultTimedLogConfig().NewLog("forgotten") // want `timed log forgotten is never completed or logged`
+	forgotten = cfg.NewLog("reassigned")
+	_ = forgotten
+
+	var declared = cfg.NewLog("declared") // want `timed log declared is never completed or logged`
+	_ = declared
+
+	cfg.NewLog("discarded") // want `timed log is discarded, it will never be completed or logged`
+
+	returned := cfg.NewLog("returned")
+	return returned
+}
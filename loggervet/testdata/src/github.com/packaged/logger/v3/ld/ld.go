@@ -0,0 +1,11 @@
+// Package ld is a minimal stub of the ld package for analyzer tests
+package ld
+
+import "go.uber.org/zap"
+
+func Prefix(prefix string, field zap.Field) zap.Field  { return field }
+func Group(name string, fields ...zap.Field) zap.Field { return zap.Field{Key: name} }
+func Secret(key string, v any) zap.Field               { return zap.Field{Key: key} }
+func InterfaceType(key string, iface any) zap.Field    { return zap.Field{Key: key + ":type"} }
+func IP(ip string) zap.Field                           { return zap.Field{Key: "ip"} }
+func UserID(id string) zap.Field                       { return zap.Field{Key: "user-id"} }
@@ -0,0 +1,27 @@
+// Package logger is a minimal stub of the logger package for analyzer tests
+package logger
+
+import "go.uber.org/zap"
+
+type Logger struct{}
+
+func I() *Logger { return nil }
+
+func (l *Logger) Clone() *Logger                                     { return l }
+func (l *Logger) AddCommon(fields ...zap.Field)                      {}
+func (l *Logger) Info(msg string, fields ...zap.Field)               {}
+func (l *Logger) ErrorIf(err error, msg string, fields ...zap.Field) {}
+func (l *Logger) WarnIf(err error, msg string, fields ...zap.Field)  {}
+func (l *Logger) TimedLog(tl *TimedLog, fields ...zap.Field)         {}
+
+type TimedLogConfig struct{}
+
+func DefaultTimedLogConfig() *TimedLogConfig { return nil }
+
+func (c *TimedLogConfig) NewLog(message string, fields ...zap.Field) *TimedLog { return nil }
+
+type TimedLog struct{}
+
+func (tl *TimedLog) Complete() {}
+
+func (tl *TimedLog) CompleteWithError(error) {}
@@ -0,0 +1,9 @@
+// Package zap is a minimal stub of go.uber.org/zap for analyzer tests
+package zap
+
+type Field struct{ Key string }
+
+func String(key string, val string) Field { return Field{Key: key} }
+func Int(key string, val int) Field       { return Field{Key: key} }
+func Any(key string, val any) Field       { return Field{Key: key} }
+func Error(err error) Field               { return Field{Key: "error"} }
@@ -0,0 +1,42 @@
+// Package loghttp provides net/http middleware and transports that log through the logger package
+package loghttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a new context with the request ID attached
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID attached to the context, or an empty string if none is set
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random 128 bit hex encoded request ID
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type traceHeadersKey struct{}
+
+// WithTraceHeaders returns a new context with the trace headers attached, for propagation by Transport
+func WithTraceHeaders(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, traceHeadersKey{}, h)
+}
+
+// TraceHeaders returns the trace headers attached to the context, or nil if none are set
+func TraceHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(traceHeadersKey{}).(http.Header)
+	return h
+}
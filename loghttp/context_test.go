@@ -0,0 +1,20 @@
+package loghttp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", RequestID(ctx))
+	assert.Equal(t, "abc", RequestID(WithRequestID(ctx, "abc")))
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	assert.Len(t, a, 32)
+	assert.NotEqual(t, a, b)
+}
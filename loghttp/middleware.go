@@ -0,0 +1,201 @@
+package loghttp
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/packaged/logger/v3/ld"
+	"github.com/packaged/logger/v3/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRequestIDHeader is the header used to receive and return the request ID
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength is the longest incoming request ID accepted, longer IDs are replaced
+const maxRequestIDLength = 128
+
+// DefaultTraceHeaders are the distributed tracing headers propagated from incoming requests by default
+var DefaultTraceHeaders = []string{"Traceparent", "Tracestate", "X-Cloud-Trace-Context"}
+
+// Config configures the access log middleware
+type Config struct {
+	// RequestIDHeader is read from incoming requests, and set on the response, to propagate the request ID
+	RequestIDHeader string
+	// TraceHeaders are attached to the request context, for propagation to outbound requests by Transport
+	TraceHeaders []string
+	// TimedLogConfig escalates the access log level for slow requests, the minimum level is always Info
+	TimedLogConfig *logger.TimedLogConfig
+	// Message is the access log message
+	Message string
+}
+
+var defaultConfig = &Config{
+	RequestIDHeader: DefaultRequestIDHeader,
+	TraceHeaders:    DefaultTraceHeaders,
+	TimedLogConfig:  logger.DefaultTimedLogConfig(),
+	Message:         "request",
+}
+
+// DefaultConfig returns the config used by Middleware
+func DefaultConfig() *Config {
+	return defaultConfig
+}
+
+// Middleware wraps the handler with DefaultConfig().Middleware
+func Middleware(next http.Handler) http.Handler {
+	return defaultConfig.Middleware(next)
+}
+
+// Middleware returns a handler which attaches a request scoped logger, with a request-id common field, to the
+// request context, then writes a single access log entry once the request has been served.
+//
+// The access log level is Error for 5xx responses, Warn for 4xx responses and Info otherwise, escalated by
+// the duration thresholds of the TimedLogConfig.  A handler which panics is logged with a 500 status, and the
+// panic continues.  Incoming request IDs which are too long, or contain characters other than letters, digits
+// and -_.:+/=, are replaced with a new ID.
+func (c *Config) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := c.TimedLogConfig.Now()
+
+		id := ""
+		if c.RequestIDHeader != "" {
+			id = r.Header.Get(c.RequestIDHeader)
+		}
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+		if c.RequestIDHeader != "" {
+			w.Header().Set(c.RequestIDHeader, id)
+		}
+
+		ctx := WithRequestID(r.Context(), id)
+		if trace := c.traceHeaders(r); trace != nil {
+			ctx = WithTraceHeaders(ctx, trace)
+		}
+		l := logger.FromContext(ctx)
+		if l != nil {
+			l = l.Clone()
+			l.AddCommon(ld.RequestID(id))
+			ctx = logger.NewContext(ctx, l)
+		}
+
+		rw := &responseWriter{ResponseWriter: w}
+		served := false
+		defer func() {
+			if l == nil {
+				return
+			}
+			status := rw.Status()
+			if !served {
+				// the handler panicked
+				status = http.StatusInternalServerError
+			}
+			duration := c.TimedLogConfig.Now().Sub(start)
+			fields := append(ld.Request(r), ld.StatusCode(status), ld.BytesOut(rw.bytes), ld.Latency(duration))
+			l.Log(c.level(status, duration), c.Message, fields...)
+		}()
+		next.ServeHTTP(rw, r.WithContext(ctx))
+		served = true
+	})
+}
+
+// validRequestID reports whether an incoming request ID is safe to propagate and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '+', c == '/', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Config) traceHeaders(r *http.Request) http.Header {
+	var h http.Header
+	for _, name := range c.TraceHeaders {
+		if values := r.Header.Values(name); len(values) > 0 {
+			if h == nil {
+				h = http.Header{}
+			}
+			h[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return h
+}
+
+func (c *Config) level(status int, duration time.Duration) zapcore.Level {
+	lvl := zapcore.InfoLevel
+	if status >= http.StatusInternalServerError {
+		lvl = zapcore.ErrorLevel
+	} else if status >= http.StatusBadRequest {
+		lvl = zapcore.WarnLevel
+	}
+	if c.TimedLogConfig != nil {
+		if durationLvl, ok := c.TimedLogConfig.Level(duration); ok && durationLvl > lvl {
+			lvl = durationLvl
+		}
+	}
+	return lvl
+}
+
+// responseWriter captures the status code and number of bytes written
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	// informational responses are followed by the final status
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the status code written, or 200 if the handler did not write one
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Flush implements http.Flusher
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("loghttp: response writer does not support hijacking")
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
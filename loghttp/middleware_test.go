@@ -0,0 +1,224 @@
+package loghttp
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/packaged/environment/environment"
+	"github.com/packaged/logger/v3/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMiddleware(t *testing.T) {
+	assert.NoError(t, logger.Setup(environment.UnitTest))
+	logs := logger.ObserverForTest()
+
+	var handlerID string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerID = RequestID(r.Context())
+		logger.FromContext(r.Context()).Info("handling")
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/path?q=1&token=secret", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "test-agent")
+	h.ServeHTTP(rec, req)
+
+	assert.Len(t, handlerID, 32)
+	assert.Equal(t, handlerID, rec.Header().Get(DefaultRequestIDHeader))
+
+	entries := logs.TakeAll()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "handling", entries[0].Message)
+	assert.Equal(t, handlerID, entries[0].ContextMap()["request-id"])
+
+	access := entries[1]
+	assert.Equal(t, "request", access.Message)
+	assert.Equal(t, zapcore.InfoLevel, access.Level)
+	ctx := access.ContextMap()
+	assert.Equal(t, handlerID, ctx["request-id"])
+	assert.Equal(t, "GET", ctx["method"])
+	assert.Equal(t, "/path", ctx["path"])
+	assert.Equal(t, "q=1&token=REDACTED", ctx["query"], "the query should be sanitised")
+	assert.NotContains(t, ctx, "url")
+	assert.Equal(t, "10.0.0.1", ctx["ip"])
+	assert.Equal(t, "test-agent", ctx["user-agent"])
+	assert.Equal(t, int64(200), ctx["status"])
+	assert.Equal(t, int64(5), ctx["bytes-out"])
+	assert.Contains(t, ctx, "latency")
+
+	// global logger is not modified
+	logger.I().Info("global")
+	assert.NotContains(t, logs.TakeAll()[0].ContextMap(), "request-id")
+}
+
+func TestMiddleware_PropagatesRequestID(t *testing.T) {
+	assert.NoError(t, logger.Setup(environment.UnitTest))
+	logs := logger.ObserverForTest()
+
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(DefaultRequestIDHeader, "upstream-id")
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, "upstream-id", rec.Header().Get(DefaultRequestIDHeader))
+	assert.Equal(t, "upstream-id", logs.TakeAll()[0].ContextMap()["request-id"])
+
+	for _, invalid := range []string{strings.Repeat("a", maxRequestIDLength+1), "id\nforged=1", "<script>"} {
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(DefaultRequestIDHeader, invalid)
+		h.ServeHTTP(rec, req)
+
+		id := logs.TakeAll()[0].ContextMap()["request-id"]
+		assert.Len(t, id, 32, "invalid request IDs should be replaced")
+		assert.Equal(t, id, rec.Header().Get(DefaultRequestIDHeader))
+	}
+}
+
+func TestMiddleware_Concurrent(t *testing.T) {
+	assert.NoError(t, logger.Setup(environment.UnitTest))
+	logs := logger.ObserverForTest()
+	base := logger.I().Clone()
+	for _, key := range []string{"service", "region", "version"} {
+		// appended one at a time, the common fields have spare capacity
+		base.AddCommon(zap.String(key, key))
+	}
+
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.FromContext(r.Context()).Info("handling", zap.String("expected-id", RequestID(r.Context())))
+	}))
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			h.ServeHTTP(httptest.NewRecorder(), req.WithContext(logger.NewContext(req.Context(), base)))
+		}()
+	}
+	wg.Wait()
+
+	entries := logs.FilterMessage("handling").All()
+	assert.Len(t, entries, 50)
+	for _, entry := range entries {
+		ctxMap := entry.ContextMap()
+		assert.Equal(t, ctxMap["expected-id"], ctxMap["request-id"], "each request should log its own ID")
+	}
+}
+
+func TestMiddleware_Panic(t *testing.T) {
+	assert.NoError(t, logger.Setup(environment.UnitTest))
+	logs := logger.ObserverForTest()
+
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+	assert.PanicsWithValue(t, "handler failed", func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}, "the panic should continue")
+
+	entries := logs.TakeAll()
+	if assert.Len(t, entries, 1, "the access log should be written") {
+		assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+		assert.Equal(t, int64(http.StatusInternalServerError), entries[0].ContextMap()["status"])
+	}
+}
+
+func TestMiddleware_Levels(t *testing.T) {
+	clock := logger.NewManualClock(time.Now())
+	cfg := &Config{
+		Message:        "access",
+		TimedLogConfig: &logger.TimedLogConfig{WarnDuration: 20 * time.Millisecond, ErrorDuration: time.Hour, Clock: clock},
+	}
+	tests := []struct {
+		name    string
+		status  int
+		elapsed time.Duration
+		level   zapcore.Level
+	}{
+		{"ok", http.StatusOK, 0, zapcore.InfoLevel},
+		{"continue", http.StatusContinue, 0, zapcore.InfoLevel},
+		{"not found", http.StatusNotFound, 0, zapcore.WarnLevel},
+		{"server error", http.StatusBadGateway, 0, zapcore.ErrorLevel},
+		{"slow", http.StatusOK, 25 * time.Millisecond, zapcore.WarnLevel},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.NoError(t, logger.Setup(environment.UnitTest))
+			logs := logger.ObserverForTest()
+
+			h := cfg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				clock.Advance(test.elapsed)
+				w.WriteHeader(test.status)
+				if test.status < http.StatusOK {
+					w.WriteHeader(http.StatusOK)
+				} else {
+					// only the first final status is recorded
+					w.WriteHeader(http.StatusTeapot)
+				}
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+			entries := logs.TakeAll()
+			assert.Len(t, entries, 1)
+			assert.Equal(t, "access", entries[0].Message)
+			assert.Equal(t, test.level, entries[0].Level)
+			assert.Empty(t, rec.Header().Get(DefaultRequestIDHeader))
+			if test.status >= http.StatusOK {
+				assert.Equal(t, int64(test.status), entries[0].ContextMap()["status"])
+			} else {
+				assert.Equal(t, int64(http.StatusOK), entries[0].ContextMap()["status"])
+			}
+		})
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := &responseWriter{ResponseWriter: rec}
+	assert.Equal(t, http.StatusOK, rw.Status())
+	rw.Flush()
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, rec, rw.Unwrap())
+	_, _, err := rw.Hijack()
+	assert.Error(t, err)
+
+	hr := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	rw = &responseWriter{ResponseWriter: hr}
+	_, _, err = rw.Hijack()
+	assert.NoError(t, err)
+	assert.True(t, hr.hijacked)
+}
+
+func TestMiddleware_NoLogger(t *testing.T) {
+	logger.Replace(nil)
+	defer func() { _ = logger.Setup(environment.UnitTest) }()
+
+	called := false
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, called)
+}
@@ -0,0 +1,232 @@
+package loghttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/packaged/logger/v3/ld"
+	"github.com/packaged/logger/v3/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultMaxBodyLog is the default number of body bytes logged when Transport.LogBodies is set
+const DefaultMaxBodyLog = 4096
+
+func init() {
+	for _, key := range []string{"request-headers", "response-headers", "request-body", "response-body"} {
+		ld.Declare(key, ld.KindAny, "outbound request and response detail, see Transport")
+	}
+}
+
+// Transport is an http.RoundTripper that logs outbound requests through logger.FromContext(req.Context()).
+// The request ID and trace headers attached to the context by Middleware are propagated to the request.
+type Transport struct {
+	// Base performs the request, http.DefaultTransport if nil
+	Base http.RoundTripper
+	// RequestIDHeader is set from the request ID attached to the context
+	RequestIDHeader string
+	// TimedLogConfig escalates the level for slow requests, the minimum level is always Info
+	TimedLogConfig *logger.TimedLogConfig
+	// Redactor masks sensitive query parameters, headers and body keys, logger.NewRedactor() if nil
+	Redactor *logger.Redactor
+	// LogHeaders adds request and response headers when the logger is at Debug
+	LogHeaders bool
+	// LogBodies adds up to MaxBodyLog bytes of the request and response bodies when the logger is at Debug.
+	// Only JSON and form encoded bodies are logged, with sensitive keys redacted; other bodies, and JSON bodies
+	// longer than MaxBodyLog, are replaced with their size.
+	LogBodies  bool
+	MaxBodyLog int
+	// Message is the log message
+	Message string
+}
+
+// NewTransport creates a logging transport wrapping base
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{
+		Base:            base,
+		RequestIDHeader: DefaultRequestIDHeader,
+		TimedLogConfig:  logger.DefaultTimedLogConfig(),
+		Redactor:        logger.NewRedactor(),
+		MaxBodyLog:      DefaultMaxBodyLog,
+		Message:         "outbound request",
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+// Entries are written at Error for transport errors and 5xx responses, and Info otherwise, escalated by the
+// duration thresholds of the TimedLogConfig.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	orig := req
+	req = t.propagate(req)
+	l := logger.FromContext(req.Context())
+	if l == nil {
+		return base.RoundTrip(req)
+	}
+
+	debug := l.Enabled(zapcore.DebugLevel)
+	fields := []zap.Field{ld.Method(req.Method), ld.URL(t.sanitizeURL(req.URL))}
+	if debug && t.LogHeaders {
+		fields = append(fields, t.headers("request-headers", req.Header))
+	}
+	if debug && t.LogBodies && req.Body != nil && req.Body != http.NoBody {
+		if req == orig {
+			// a RoundTripper must not modify the provided request
+			req = req.Clone(req.Context())
+		}
+		var body []byte
+		var truncated bool
+		body, truncated, req.Body = t.peek(req.Body)
+		fields = append(fields, t.body("request-body", body, truncated, req.ContentLength, req.Header))
+	}
+
+	start := t.TimedLogConfig.Now()
+	resp, err := base.RoundTrip(req)
+	duration := t.TimedLogConfig.Now().Sub(start)
+
+	lvl := zapcore.InfoLevel
+	if err != nil {
+		lvl = zapcore.ErrorLevel
+		fields = append(fields, ld.Error(err))
+	} else {
+		if resp.StatusCode >= http.StatusInternalServerError {
+			lvl = zapcore.ErrorLevel
+		}
+		fields = append(fields, ld.StatusCode(resp.StatusCode))
+		if resp.ContentLength >= 0 {
+			fields = append(fields, ld.BytesIn(resp.ContentLength))
+		}
+		if debug && t.LogHeaders {
+			fields = append(fields, t.headers("response-headers", resp.Header))
+		}
+		if debug && t.LogBodies && resp.Body != nil {
+			var body []byte
+			var truncated bool
+			body, truncated, resp.Body = t.peek(resp.Body)
+			fields = append(fields, t.body("response-body", body, truncated, resp.ContentLength, resp.Header))
+		}
+	}
+	if t.TimedLogConfig != nil {
+		if durationLvl, ok := t.TimedLogConfig.Level(duration); ok && durationLvl > lvl {
+			lvl = durationLvl
+		}
+	}
+	fields = append(fields, ld.Latency(duration))
+
+	l.Log(lvl, t.Message, fields...)
+	return resp, err
+}
+
+// propagate returns a clone of the request with the request ID and trace headers from the context set
+func (t *Transport) propagate(req *http.Request) *http.Request {
+	set := http.Header{}
+	if id := RequestID(req.Context()); id != "" && t.RequestIDHeader != "" && req.Header.Get(t.RequestIDHeader) == "" {
+		set.Set(t.RequestIDHeader, id)
+	}
+	for name, values := range TraceHeaders(req.Context()) {
+		if req.Header.Get(name) == "" {
+			set[name] = values
+		}
+	}
+	if len(set) == 0 {
+		return req
+	}
+
+	// a RoundTripper must not modify the provided request
+	req = req.Clone(req.Context())
+	for name, values := range set {
+		req.Header[name] = values
+	}
+	return req
+}
+
+// sanitizeURL removes any password and redacts sensitive query parameters
+func (t *Transport) sanitizeURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clean := *u
+	clean.RawQuery = t.sanitizeQuery(clean.RawQuery)
+	return clean.Redacted()
+}
+
+// defaultRedactor is used by transports without a Redactor
+var defaultRedactor = logger.NewRedactor()
+
+func (t *Transport) redactor() *logger.Redactor {
+	if t.Redactor == nil {
+		return defaultRedactor
+	}
+	return t.Redactor
+}
+
+// sanitizeQuery redacts the values of sensitive keys from a query string or form encoded body
+func (t *Transport) sanitizeQuery(raw string) string {
+	return ld.SanitizeQueryFunc(raw, t.redactor().Matches)
+}
+
+func (t *Transport) headers(key string, h http.Header) zap.Field {
+	flat := make(map[string]string, len(h))
+	for name, values := range h {
+		flat[name] = strings.Join(values, ", ")
+	}
+	return t.redact(zap.Any(key, flat))
+}
+
+// body returns a field for a JSON or form encoded body with sensitive keys redacted, or the size of any other
+// body, as it cannot be redacted
+func (t *Transport) body(key string, body []byte, truncated bool, length int64, h http.Header) zap.Field {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		// a truncated form loses only the trailing value, which is redacted if its key is sensitive
+		return zap.String(key, t.sanitizeQuery(string(body)))
+	case !truncated && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")):
+		var parsed any
+		if json.Unmarshal(body, &parsed) == nil {
+			return t.redact(zap.Any(key, parsed))
+		}
+	}
+
+	size := ld.ByteSize(len(body)).String()
+	if length >= 0 {
+		size = ld.ByteSize(length).String()
+	} else if truncated {
+		size = "over " + size
+	}
+	return zap.String(key, "[body not logged, "+size+"]")
+}
+
+func (t *Transport) redact(f zap.Field) zap.Field {
+	return t.redactor().Redact([]zap.Field{f})[0]
+}
+
+// peek reads up to MaxBodyLog bytes from the body, returning them, whether the body is longer, and a body that
+// replays everything read
+func (t *Transport) peek(body io.ReadCloser) ([]byte, bool, io.ReadCloser) {
+	limit := t.MaxBodyLog
+	if limit <= 0 {
+		limit = DefaultMaxBodyLog
+	}
+	read, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	replay := &replayBody{Reader: io.MultiReader(bytes.NewReader(read), body), Closer: body}
+	if len(read) > limit {
+		return read[:limit], true, replay
+	}
+	return read, false, replay
+}
+
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
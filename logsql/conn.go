@@ -0,0 +1,259 @@
+package logsql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+var errNamedArgs = errors.New("logsql: driver does not support named arguments")
+
+type conn struct {
+	conn driver.Conn
+	cfg  *Config
+}
+
+// Prepare implements driver.Conn
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	st := c.cfg.start(ctx, "sql prepare", query, nil)
+	var s driver.Stmt
+	var err error
+	if pc, ok := c.conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else if err = ctx.Err(); err == nil {
+		s, err = c.conn.Prepare(query)
+	}
+	st.end(err)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{stmt: s, query: query, cfg: c.cfg}, nil
+}
+
+// Close implements driver.Conn
+func (c *conn) Close() error { return c.conn.Close() }
+
+// Begin implements driver.Conn
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	st := c.cfg.start(ctx, "sql begin", "", nil)
+	var tx driver.Tx
+	var err error
+	if bc, ok := c.conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else if opts.Isolation != 0 || opts.ReadOnly {
+		err = errors.New("logsql: driver does not support transaction options")
+	} else if err = ctx.Err(); err == nil {
+		//nolint:staticcheck // fallback for drivers without ConnBeginTx
+		tx, err = c.conn.Begin()
+	}
+	st.end(err)
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedTx{tx: tx, ctx: ctx, cfg: c.cfg}, nil
+}
+
+// ExecContext implements driver.ExecerContext
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	var res driver.Result
+	var err error
+	switch e := c.conn.(type) {
+	case driver.ExecerContext:
+		st := c.cfg.start(ctx, "sql exec", query, args)
+		res, err = e.ExecContext(ctx, query, args)
+		st.end(err, rowsAffected(res)...)
+	case driver.Execer: //nolint:staticcheck // fallback for drivers without ExecerContext
+		st := c.cfg.start(ctx, "sql exec", query, args)
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			res, err = e.Exec(query, values)
+		}
+		st.end(err, rowsAffected(res)...)
+	default:
+		return nil, driver.ErrSkip
+	}
+	return res, err
+}
+
+// QueryContext implements driver.QueryerContext
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	var err error
+	switch q := c.conn.(type) {
+	case driver.QueryerContext:
+		st := c.cfg.start(ctx, "sql query", query, args)
+		rows, err = q.QueryContext(ctx, query, args)
+		st.end(err)
+	case driver.Queryer: //nolint:staticcheck // fallback for drivers without QueryerContext
+		st := c.cfg.start(ctx, "sql query", query, args)
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			rows, err = q.Query(query, values)
+		}
+		st.end(err)
+	default:
+		return nil, driver.ErrSkip
+	}
+	return rows, err
+}
+
+// Ping implements driver.Pinger
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator
+func (c *conn) IsValid() bool {
+	if v, ok := c.conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type stmt struct {
+	stmt  driver.Stmt
+	query string
+	cfg   *Config
+}
+
+// Close implements driver.Stmt
+func (s *stmt) Close() error { return s.stmt.Close() }
+
+// NumInput implements driver.Stmt
+func (s *stmt) NumInput() int { return s.stmt.NumInput() }
+
+// Exec implements driver.Stmt
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), valuesNamed(args))
+}
+
+// Query implements driver.Stmt
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), valuesNamed(args))
+}
+
+// ExecContext implements driver.StmtExecContext
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	st := s.cfg.start(ctx, "sql exec", s.query, args)
+	var res driver.Result
+	var err error
+	if e, ok := s.stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			//nolint:staticcheck // fallback for statements without StmtExecContext
+			res, err = s.stmt.Exec(values)
+		}
+	}
+	st.end(err, rowsAffected(res)...)
+	return res, err
+}
+
+// QueryContext implements driver.StmtQueryContext
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	st := s.cfg.start(ctx, "sql query", s.query, args)
+	var rows driver.Rows
+	var err error
+	if q, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValues(args); err == nil {
+			//nolint:staticcheck // fallback for statements without StmtQueryContext
+			rows, err = s.stmt.Query(values)
+		}
+	}
+	st.end(err)
+	return rows, err
+}
+
+// CheckNamedValue implements driver.NamedValueChecker
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type wrappedTx struct {
+	tx  driver.Tx
+	ctx context.Context
+	cfg *Config
+}
+
+// Commit implements driver.Tx
+func (t *wrappedTx) Commit() error {
+	st := t.cfg.start(t.ctx, "sql commit", "", nil)
+	err := t.tx.Commit()
+	st.end(err)
+	return err
+}
+
+// Rollback implements driver.Tx
+func (t *wrappedTx) Rollback() error {
+	st := t.cfg.start(t.ctx, "sql rollback", "", nil)
+	err := t.tx.Rollback()
+	st.end(err)
+	return err
+}
+
+func rowsAffected(res driver.Result) []zap.Field {
+	if res == nil {
+		return nil
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		return []zap.Field{zap.Int64("rows-affected", n)}
+	}
+	return nil
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, errNamedArgs
+		}
+		values[i] = a.Value
+	}
+	return values, nil
+}
+
+func valuesNamed(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
@@ -0,0 +1,150 @@
+package logsql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/packaged/logger/v3/ld"
+	"github.com/packaged/logger/v3/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestConn_Exec(t *testing.T) {
+	db := openDB(t, nil)
+	ctx := context.Background()
+	logs := logger.ObserverForTest()
+
+	_, err := db.ExecContext(ctx, "UPDATE items SET name = ? WHERE id = ?", "secret", 1)
+	assert.NoError(t, err)
+
+	entries := logs.FilterMessage("sql exec").All()
+	if assert.Len(t, entries, 1) {
+		ctxMap := entries[0].ContextMap()
+		assert.Equal(t, zapcore.DebugLevel, entries[0].Level)
+		assert.Equal(t, "UPDATE items SET name = ? WHERE id = ?", ctxMap["sql"])
+		assert.Equal(t, []any{ld.RedactedMask, ld.RedactedMask}, ctxMap["args"], "args should be redacted by default")
+		assert.Equal(t, int64(3), ctxMap["rows-affected"])
+		assert.Contains(t, ctxMap, "duration")
+	}
+}
+
+func TestConn_LogArgs(t *testing.T) {
+	db := openDB(t, &Config{LogArgs: true})
+	ctx := context.Background()
+	logs := logger.ObserverForTest()
+
+	_, err := db.ExecContext(ctx, "UPDATE items SET name = ?", "visible")
+	assert.NoError(t, err)
+
+	entries := logs.FilterMessage("sql exec").All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, []any{"visible"}, entries[0].ContextMap()["args"])
+	}
+}
+
+func TestConn_Error(t *testing.T) {
+	db := openDB(t, nil)
+	ctx := context.Background()
+	logs := logger.ObserverForTest()
+
+	_, err := db.ExecContext(ctx, "fail")
+	assert.ErrorIs(t, err, errFake)
+	_, err = db.QueryContext(ctx, "fail")
+	assert.ErrorIs(t, err, errFake)
+
+	entries := logs.All()
+	if assert.Len(t, entries, 2) {
+		for _, entry := range entries {
+			assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+			assert.Equal(t, errFake.Error(), entry.ContextMap()["error"])
+			assert.NotContains(t, entry.ContextMap(), "rows-affected")
+		}
+		assert.Equal(t, "sql exec", entries[0].Message)
+		assert.Equal(t, "sql query", entries[1].Message)
+	}
+}
+
+func TestConn_Slow(t *testing.T) {
+	db := openDB(t, &Config{TimedLogConfig: &logger.TimedLogConfig{
+		WarnDuration:  10 * time.Millisecond,
+		DebugDuration: time.Nanosecond,
+		Clock:         testClock,
+	}})
+	ctx := context.Background()
+	logs := logger.ObserverForTest()
+
+	rows, err := db.QueryContext(ctx, "SELECT slow")
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+
+	entries := logs.FilterMessage("sql query").All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, zapcore.WarnLevel, entries[0].Level, "slow statements should escalate")
+	}
+}
+
+func TestConn_Skip(t *testing.T) {
+	clock := logger.NewManualClock(time.Now())
+	cfg := &logger.TimedLogConfig{InfoDuration: time.Second, Watchdog: true, Clock: clock}
+	db := openDB(t, &Config{TimedLogConfig: cfg})
+	ctx := context.Background()
+	logs := logger.ObserverForTest()
+
+	_, err := db.ExecContext(ctx, "UPDATE skip")
+	assert.NoError(t, err)
+	// a log still running when the clock advances shows when the watchdogs have run
+	running := cfg.NewDeadlineLog(ctx, "running")
+	clock.Advance(time.Minute)
+	assert.Eventually(t, func() bool { return logs.FilterMessage("running").Len() == 1 }, time.Second, time.Millisecond)
+	running.Complete()
+
+	assert.Equal(t, 1, logs.FilterField(zap.Bool("still-running", true)).Len(), "the skipped statement should stop its watchdog")
+}
+
+func TestStmt(t *testing.T) {
+	db := openDB(t, nil)
+	ctx := context.Background()
+	logs := logger.ObserverForTest()
+
+	st, err := db.PrepareContext(ctx, "INSERT INTO items VALUES (?)")
+	assert.NoError(t, err)
+	_, err = st.ExecContext(ctx, 1)
+	assert.NoError(t, err)
+	var value int
+	assert.NoError(t, st.QueryRowContext(ctx, 1).Scan(&value))
+	assert.Equal(t, 1, value)
+	assert.NoError(t, st.Close())
+
+	assert.Equal(t, 1, logs.FilterMessage("sql prepare").Len())
+	exec := logs.FilterMessage("sql exec").All()
+	if assert.Len(t, exec, 1) {
+		assert.Equal(t, "INSERT INTO items VALUES (?)", exec[0].ContextMap()["sql"])
+		assert.Equal(t, int64(1), exec[0].ContextMap()["rows-affected"])
+	}
+	assert.Equal(t, 1, logs.FilterMessage("sql query").Len())
+}
+
+func TestTx(t *testing.T) {
+	db := openDB(t, nil)
+	ctx := context.Background()
+	logs := logger.ObserverForTest()
+
+	tx, err := db.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	_, err = tx.ExecContext(ctx, "DELETE FROM items")
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	tx, err = db.BeginTx(ctx, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Rollback())
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"sql begin", "sql exec", "sql commit", "sql begin", "sql rollback"}, messages)
+}
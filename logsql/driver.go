@@ -0,0 +1,178 @@
+// Package logsql wraps database/sql drivers to log and time every query, exec and transaction through
+// the logger attached to the context
+package logsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/packaged/logger/v3/ld"
+	"github.com/packaged/logger/v3/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Config configures how statements are logged
+type Config struct {
+	// TimedLogConfig selects the level from the statement duration, slow statements escalate to Warn or Error.
+	// If nil, the TimedLogConfig of DefaultConfig is used.
+	TimedLogConfig *logger.TimedLogConfig
+	// LogArgs logs statement arguments, which are redacted by default
+	LogArgs bool
+}
+
+var defaultConfig = &Config{
+	TimedLogConfig: &logger.TimedLogConfig{
+		ErrorDuration: 10 * time.Second,
+		WarnDuration:  time.Second,
+		InfoDuration:  100 * time.Millisecond,
+		DebugDuration: time.Nanosecond,
+		Outcome:       FailureLevel,
+	},
+}
+
+func init() {
+	ld.Declare("sql", ld.KindString, "SQL statement text")
+	ld.Declare("args", ld.KindAny, "statement arguments, redacted unless Config.LogArgs is set")
+	ld.Declare("rows-affected", ld.KindInt, "rows affected by a statement")
+}
+
+// DefaultConfig logs every statement at Debug, escalating to Info at 100ms, Warn at 1s and Error at 10s,
+// with arguments redacted.  Failed statements are logged at Error, see FailureLevel.
+func DefaultConfig() *Config {
+	return defaultConfig
+}
+
+// FailureLevel logs statements cancelled through their context at Info, and any other failure at Error
+func FailureLevel(err error) zapcore.Level {
+	if errors.Is(err, context.Canceled) {
+		return zapcore.InfoLevel
+	}
+	return zapcore.ErrorLevel
+}
+
+// Register wraps the driver and registers it with database/sql under the provided name
+func Register(name string, d driver.Driver, cfg *Config) {
+	sql.Register(name, Wrap(d, cfg))
+}
+
+// Wrap returns a driver that logs statements executed through connections opened by d.
+// A nil config uses DefaultConfig.
+func Wrap(d driver.Driver, cfg *Config) driver.Driver {
+	if cfg == nil {
+		cfg = defaultConfig
+	}
+	return &wrappedDriver{driver: d, cfg: cfg}
+}
+
+// NewConnector returns a connector, for use with sql.OpenDB, that logs statements executed through
+// connections created by c.  A nil config uses DefaultConfig.
+func NewConnector(c driver.Connector, cfg *Config) driver.Connector {
+	if cfg == nil {
+		cfg = defaultConfig
+	}
+	return &connector{connector: c, driver: &wrappedDriver{driver: c.Driver(), cfg: cfg}}
+}
+
+type wrappedDriver struct {
+	driver driver.Driver
+	cfg    *Config
+}
+
+// Open implements driver.Driver
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{conn: c, cfg: d.cfg}, nil
+}
+
+// OpenConnector implements driver.DriverContext
+func (d *wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.driver.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &connector{connector: c, driver: d}, nil
+	}
+	return &connector{connector: dsnConnector{name: name, driver: d.driver}, driver: d}, nil
+}
+
+type connector struct {
+	connector driver.Connector
+	driver    *wrappedDriver
+}
+
+// Connect implements driver.Connector
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{conn: cn, cfg: c.driver.cfg}, nil
+}
+
+// Driver implements driver.Connector
+func (c *connector) Driver() driver.Driver { return c.driver }
+
+// dsnConnector adapts drivers that do not implement driver.DriverContext
+type dsnConnector struct {
+	name   string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open(c.name) }
+
+func (c dsnConnector) Driver() driver.Driver { return c.driver }
+
+// statement is a timed log for a single driver operation
+type statement struct {
+	ctx context.Context
+	tl  *logger.TimedLog
+}
+
+func (c *Config) start(ctx context.Context, msg, query string, args []driver.NamedValue) *statement {
+	fields := make([]zap.Field, 0, 2)
+	if query != "" {
+		fields = append(fields, zap.String("sql", query))
+	}
+	if len(args) > 0 {
+		fields = append(fields, c.args(args))
+	}
+	return &statement{ctx: ctx, tl: c.timedLogConfig().NewDeadlineLog(ctx, msg, fields...)}
+}
+
+func (c *Config) timedLogConfig() *logger.TimedLogConfig {
+	if c.TimedLogConfig == nil {
+		return defaultConfig.TimedLogConfig
+	}
+	return c.TimedLogConfig
+}
+
+func (c *Config) args(args []driver.NamedValue) zap.Field {
+	values := make([]any, len(args))
+	for i, a := range args {
+		if c.LogArgs {
+			values[i] = a.Value
+		} else {
+			values[i] = ld.RedactedMask
+		}
+	}
+	return zap.Any("args", values)
+}
+
+// end writes the entry for the statement, escalated by the TimedLogConfig outcome rule when it failed
+func (s *statement) end(err error, fields ...zap.Field) {
+	if err == driver.ErrSkip {
+		// database/sql falls back to another path, which is logged instead, so only stop any watchdog
+		s.tl.Complete()
+		return
+	}
+	s.tl.CompleteWithError(err)
+	logger.FromContext(s.ctx).TimedLog(s.tl, fields...)
+}
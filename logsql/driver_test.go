@@ -0,0 +1,154 @@
+package logsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/environment/environment"
+	"github.com/packaged/logger/v3/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+var errFake = errors.New("fake: statement failed")
+
+// fakeDriver is an in-memory driver, statements containing "fail" return an error
+// and statements containing "slow" advance testClock before returning.  Connections return driver.ErrSkip from
+// ExecContext for statements containing "skip", so database/sql prepares them instead.
+type fakeDriver struct {
+	opened atomic.Int32
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	d.opened.Add(1)
+	return &fakeConn{}, nil
+}
+
+type fakeConnector struct{ driver *fakeDriver }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.driver.Open("") }
+func (c fakeConnector) Driver() driver.Driver                        { return c.driver }
+
+type fakeConn struct{}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{query: query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if strings.Contains(query, "skip") {
+		return nil, driver.ErrSkip
+	}
+	if err := run(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(3), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := run(query); err != nil {
+		return nil, err
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeStmt struct{ query string }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if err := run(s.query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	if err := run(s.query); err != nil {
+		return nil, err
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// fakeRows returns a single row with a single column
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+// testClock times statements run by configs using it, slow statements advance it
+var testClock = logger.NewManualClock(time.Now())
+
+func run(query string) error {
+	if strings.Contains(query, "slow") {
+		testClock.Advance(20 * time.Millisecond)
+	}
+	if strings.Contains(query, "fail") {
+		return errFake
+	}
+	return nil
+}
+
+func openDB(t *testing.T, cfg *Config) *sql.DB {
+	assert.NoError(t, logger.Setup(environment.UnitTest))
+	db := sql.OpenDB(NewConnector(fakeConnector{driver: &fakeDriver{}}, cfg))
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// database/sql panics when a driver name is registered twice, so registration must survive -count
+var (
+	registerOnce sync.Once
+	registered   = &fakeDriver{}
+)
+
+func TestRegister(t *testing.T) {
+	assert.NoError(t, logger.Setup(environment.UnitTest))
+	logs := logger.ObserverForTest()
+	registerOnce.Do(func() { Register("logsql-fake", registered, nil) })
+	opened := registered.opened.Load()
+
+	db, err := sql.Open("logsql-fake", "")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err = db.ExecContext(ctx, "DELETE FROM items")
+	assert.NoError(t, err)
+	assert.Equal(t, opened+1, registered.opened.Load())
+
+	entries := logs.FilterMessage("sql exec").All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "DELETE FROM items", entries[0].ContextMap()["sql"])
+		assert.Equal(t, int64(3), entries[0].ContextMap()["rows-affected"])
+	}
+}
+
+func TestFailureLevel(t *testing.T) {
+	assert.Equal(t, zapcore.ErrorLevel, FailureLevel(errFake))
+	assert.Equal(t, zapcore.InfoLevel, FailureLevel(fmt.Errorf("query: %w", context.Canceled)))
+}